@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// Trace replay benchmarks: every policy is driven with the same synthetic
+// access traces and reports its hit ratio alongside the usual timings.
+//
+//	go test ./cache_benchmark_test/ -run x -bench TraceReplay
+
+const (
+	traceLength   = 1 << 18
+	traceCapacity = 1000
+)
+
+var tracePolicies = []struct {
+	name    string
+	factory cache.PolicyFactory
+}{
+	{"LRU", cache.NewLRUPolicy},
+	{"LFU", cache.NewLFUPolicy},
+	{"ARC", cache.NewARCPolicy},
+	{"2Q", cache.New2QPolicy},
+	{"TinyLFU", cache.NewTinyLFUPolicy},
+}
+
+func BenchmarkPolicy_TraceReplay(b *testing.B) {
+	traces := []struct {
+		name string
+		keys []string
+	}{
+		{"zipf", zipfTrace(1, 1.1, 100000, traceLength)},
+		{"scan-mix", scanMixTrace(2, traceLength)},
+		{"loop", loopTrace(traceCapacity*3/2, traceLength)},
+		{"shifting", shiftingTrace(3, traceLength)},
+	}
+
+	for _, trace := range traces {
+		for _, policy := range tracePolicies {
+			b.Run(trace.name+"/"+policy.name, func(b *testing.B) {
+				c := cache.NewLRUCache(traceCapacity, cache.WithPolicy(policy.factory))
+				hits := 0
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					key := trace.keys[i%len(trace.keys)]
+					if _, err := c.Get(key); err == nil {
+						hits++
+						continue
+					}
+					c.Set(key, "value", time.Hour)
+				}
+				b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+			})
+		}
+	}
+}
+
+// zipfTrace draws keys from a Zipf distribution, the typical web cache shape
+func zipfTrace(seed int64, s float64, keys uint64, length int) []string {
+	r := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(r, s, 1, keys-1)
+	trace := make([]string, length)
+	for i := range trace {
+		trace[i] = fmt.Sprintf("k%d", zipf.Uint64())
+	}
+	return trace
+}
+
+// scanMixTrace interleaves a Zipf workload with long one-off sequential scans
+func scanMixTrace(seed int64, length int) []string {
+	zipf := zipfTrace(seed, 1.1, 20000, length)
+	trace := make([]string, 0, length)
+	scan := 0
+	for i := 0; len(trace) < length; i++ {
+		if i%20000 == 10000 {
+			for j := 0; j < 5000 && len(trace) < length; j++ {
+				trace = append(trace, fmt.Sprintf("scan%d", scan))
+				scan++
+			}
+		}
+		trace = append(trace, zipf[i%len(zipf)])
+	}
+	return trace
+}
+
+// loopTrace cycles over a key set slightly larger than the cache, the worst
+// case for LRU
+func loopTrace(keys, length int) []string {
+	trace := make([]string, length)
+	for i := range trace {
+		trace[i] = fmt.Sprintf("loop%d", i%keys)
+	}
+	return trace
+}
+
+// shiftingTrace changes its popular key set every phase, rewarding policies
+// that forget old frequencies
+func shiftingTrace(seed int64, length int) []string {
+	const phases = 4
+	trace := make([]string, 0, length)
+	for phase := 0; phase < phases; phase++ {
+		for _, key := range zipfTrace(seed+int64(phase), 1.1, 50000, length/phases) {
+			trace = append(trace, fmt.Sprintf("p%d-%s", phase, key))
+		}
+	}
+	return trace
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+const parallelKeySpace = 1 << 14
+
+var parallelKeys = func() []string {
+	keys := make([]string, parallelKeySpace)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+	return keys
+}()
+
+// runParallelMixed drives c from GOMAXPROCS goroutines with a 90/10 read/write
+// mix over a key space larger than the cache, so evictions happen as well.
+func runParallelMixed(b *testing.B, c cache.Cache) {
+	for _, key := range parallelKeys {
+		c.Set(key, "value", time.Minute)
+	}
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := parallelKeys[i%parallelKeySpace]
+			if i%10 == 0 {
+				c.Set(key, "value", time.Minute)
+			} else {
+				_, _ = c.Get(key)
+			}
+			i += 7
+		}
+	})
+}
+
+func BenchmarkLRUCache_ParallelMixed(b *testing.B) {
+	runParallelMixed(b, cache.NewLRUCache(parallelKeySpace/2))
+}
+
+func BenchmarkShardedLRUCache_ParallelMixed(b *testing.B) {
+	runParallelMixed(b, cache.NewShardedLRUCache(parallelKeySpace/2, cache.DefaultShardCount))
+}
+
+func BenchmarkLRUCache_ParallelGet(b *testing.B) {
+	c := cache.NewLRUCache(parallelKeySpace)
+	for _, key := range parallelKeys {
+		c.Set(key, "value", time.Minute)
+	}
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = c.Get(parallelKeys[i%parallelKeySpace])
+			i++
+		}
+	})
+}
+
+func BenchmarkShardedLRUCache_ParallelGet(b *testing.B) {
+	c := cache.NewShardedLRUCache(parallelKeySpace, cache.DefaultShardCount)
+	for _, key := range parallelKeys {
+		c.Set(key, "value", time.Minute)
+	}
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = c.Get(parallelKeys[i%parallelKeySpace])
+			i++
+		}
+	})
+}
@@ -3,8 +3,151 @@ package config
 import "time"
 
 type CacheConfig struct {
-	RedisAddr        string
+	RedisAddr string
+	// MemcachedServers lists "host:port" or "host:port weight" entries;
+	// keys are spread over them by ketama consistent hashing
 	MemcachedServers []string
 	MaxLRUSize       int
-	DefaultTTL       time.Duration
+	// DefaultTTL applies to writes that do not give a TTL. MinTTL and
+	// MaxTTL bound the TTL clients may ask for; zero leaves a bound open,
+	// and a MaxTTL rules out entries without expiry.
+	DefaultTTL time.Duration
+	MinTTL     time.Duration
+	MaxTTL     time.Duration
+
+	// RedisTimeout and MemcachedTimeout bound each backend operation. Zero
+	// leaves the backend's own default in place.
+	RedisTimeout     time.Duration
+	MemcachedTimeout time.Duration
+
+	// MemcachedKeyIndex records the keys written to memcached so they can
+	// be listed, since memcached cannot list them itself
+	MemcachedKeyIndex bool
+	// MemcachedDeadRetry is how long an unreachable memcached server is left
+	// out before it is tried again. Zero uses cache.DefaultDeadRetry.
+	MemcachedDeadRetry time.Duration
+
+	// TierOrder lists the backends a read without ?cache= consults, fastest
+	// first. Empty uses the order the backends were registered in.
+	TierOrder []string
+	// WritePolicy is the default policy of writes to every tier: "all",
+	// "best-effort", "quorum" or "primary". Empty means "all".
+	WritePolicy string
+	// MaxBodyBytes limits the size of POST and PUT request bodies. Zero uses
+	// api.DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+
+	// Redis holds the Redis connection settings beyond RedisAddr
+	Redis RedisConfig
+
+	// Codec encodes the values Redis and Memcached store: "json" (the
+	// default), "gob" or "raw". Encoded values longer than CompressAbove
+	// bytes are gzipped; zero disables compression.
+	Codec         string
+	CompressAbove int
+
+	// Backends declares any number of named backends. When empty, the
+	// fields above declare the standard "inMemory", "redis" and "memcached"
+	// backends.
+	Backends []BackendConfig
+}
+
+// BackendConfig declares a named backend. Type is "lru", "redis" or
+// "memcached"; the fields of the other types are ignored.
+type BackendConfig struct {
+	Name string
+	Type string
+
+	MaxLRUSize int
+
+	RedisAddr    string
+	Redis        RedisConfig
+	RedisTimeout time.Duration
+
+	MemcachedServers   []string
+	MemcachedTimeout   time.Duration
+	MemcachedKeyIndex  bool
+	MemcachedDeadRetry time.Duration
+
+	// Codec and CompressAbove apply to the remote types, as in CacheConfig
+	Codec         string
+	CompressAbove int
+}
+
+// BackendList returns the declared backends, or the standard three built
+// from the top-level fields when none are declared
+func (c CacheConfig) BackendList() []BackendConfig {
+	if len(c.Backends) > 0 {
+		return c.Backends
+	}
+	return []BackendConfig{
+		{Name: "inMemory", Type: "lru", MaxLRUSize: c.MaxLRUSize},
+		{
+			Name:          "redis",
+			Type:          "redis",
+			RedisAddr:     c.RedisAddr,
+			Redis:         c.Redis,
+			RedisTimeout:  c.RedisTimeout,
+			Codec:         c.Codec,
+			CompressAbove: c.CompressAbove,
+		},
+		{
+			Name:               "memcached",
+			Type:               "memcached",
+			MemcachedServers:   c.MemcachedServers,
+			MemcachedTimeout:   c.MemcachedTimeout,
+			MemcachedKeyIndex:  c.MemcachedKeyIndex,
+			MemcachedDeadRetry: c.MemcachedDeadRetry,
+			Codec:              c.Codec,
+			CompressAbove:      c.CompressAbove,
+		},
+	}
+}
+
+// RedisConfig mirrors cache.RedisOptions. Zero values keep the client
+// defaults.
+type RedisConfig struct {
+	// Addrs adds Sentinel or cluster seed nodes to RedisAddr. MasterName
+	// selects Sentinel failover; Cluster or several addresses select Redis
+	// Cluster.
+	Addrs            []string
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+	Cluster          bool
+	ReadOnly         bool
+
+	Username string
+	Password string
+	DB       int
+
+	TLS                   bool
+	TLSCAFile             string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	PoolSize     int
+	MinIdleConns int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// Default returns the configuration of a local development setup
+func Default() CacheConfig {
+	return CacheConfig{
+		RedisAddr:        "localhost:6379",
+		MemcachedServers: []string{"localhost:11211"},
+		MaxLRUSize:       5,
+		DefaultTTL:       time.Minute,
+		RedisTimeout:     500 * time.Millisecond,
+		MemcachedTimeout: 500 * time.Millisecond,
+
+		MemcachedKeyIndex: true,
+	}
 }
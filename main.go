@@ -4,13 +4,14 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/config"
 	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
 	"github.com/gorilla/mux"
 )
 
 func main() {
 	// Initialize the caches
-	unifiedCache, err := api.InitCache()
+	unifiedCache, err := api.InitCache(config.Default())
 	if err != nil {
 		log.Fatalf("Failed to initialize caches: %v", err)
 	}
@@ -18,12 +19,24 @@ func main() {
 	r := mux.NewRouter()
 
 	// Register handlers
-	r.HandleFunc("/cache/{key}", api.HandleCacheRequest(unifiedCache)).Methods("GET", "DELETE", "POST")
+	r.HandleFunc("/cache/{key}", api.HandleCacheRequest(unifiedCache)).Methods("GET", "DELETE", "POST", "PUT")
 	r.HandleFunc("/cache", api.HandleGetAllCacheRequest(unifiedCache)).Methods("GET")
+	r.HandleFunc("/cache", api.HandleDeletePrefixRequest(unifiedCache)).Methods("DELETE")
+	r.HandleFunc("/stats", api.HandleStatsRequest(unifiedCache)).Methods("GET")
+	r.HandleFunc("/backends", api.HandleBackendsRequest(unifiedCache)).Methods("GET")
 
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
 
+// PUT /cache/{key} stores the raw body with its Content-Type, for images and
+// other binary values; GET serves it back with the same Content-Type.
+
+// POST and PUT take ?set=nx or ?set=xx to write only if the key is absent or
+// present, and a POST body with "old" swaps only if the key holds that value.
+
+// Any backend registered in config.CacheConfig.Backends is addressed by its
+// name in ?cache=; GET /backends lists them with their health.
+
 //Inmemory ::
 // post -- http://localhost:8080/cache/d6
 // get -- http://localhost:8080/cache/d4?cache=inMemory
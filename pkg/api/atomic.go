@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// setIfAbsent is the write condition of ?set=nx: the key must not be set
+func setIfAbsent(ctx context.Context, backend cache.Cache, key string, value interface{}, ttl time.Duration) error {
+	atomic, ok := backend.(cache.AtomicCache)
+	if !ok {
+		return fmt.Errorf("%w: conditional writes", errUnsupported)
+	}
+	stored, err := atomic.SetNX(ctx, key, value, ttl)
+	if err == nil && !stored {
+		err = errKeyExists
+	}
+	return err
+}
+
+// setIfPresent is the write condition of ?set=xx: the key must be set
+func setIfPresent(ctx context.Context, backend cache.Cache, key string, value interface{}, ttl time.Duration) error {
+	atomic, ok := backend.(cache.AtomicCache)
+	if !ok {
+		return fmt.Errorf("%w: conditional writes", errUnsupported)
+	}
+	stored, err := atomic.SetXX(ctx, key, value, ttl)
+	if err == nil && !stored {
+		err = fmt.Errorf("%w: key is not set", cache.ErrVersionMismatch)
+	}
+	return err
+}
+
+// swapIfEqual is the write condition of a write with an "old" value: the key
+// must hold a value equal to old
+func swapIfEqual(old interface{}) writeCondition {
+	return func(ctx context.Context, backend cache.Cache, key string, value interface{}, ttl time.Duration) error {
+		atomic, ok := backend.(cache.AtomicCache)
+		if !ok {
+			return fmt.Errorf("%w: conditional writes", errUnsupported)
+		}
+		swapped, err := atomic.CompareAndSwap(ctx, key, old, value, ttl)
+		if err == nil && !swapped {
+			err = fmt.Errorf("%w: key does not hold the old value", cache.ErrVersionMismatch)
+		}
+		return err
+	}
+}
+
+// requestCondition returns the condition of a write: If-Match, ?set=nx or
+// ?set=xx, or a compare-and-swap when old is not nil. It is nil for an
+// unconditional write, and at most one condition may be given.
+func requestCondition(r *http.Request, old interface{}) (writeCondition, error) {
+	var conditions []writeCondition
+	etag, err := requestIfMatch(r)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" {
+		conditions = append(conditions, ifMatch(etag))
+	}
+	switch set := r.URL.Query().Get("set"); set {
+	case "":
+	case "nx":
+		conditions = append(conditions, setIfAbsent)
+	case "xx":
+		conditions = append(conditions, setIfPresent)
+	default:
+		return nil, fmt.Errorf("%w: unknown set mode %q, want nx or xx", errInvalidPrecondition, set)
+	}
+	if old != nil {
+		conditions = append(conditions, swapIfEqual(old))
+	}
+
+	switch len(conditions) {
+	case 0:
+		return nil, nil
+	case 1:
+		return conditions[0], nil
+	default:
+		return nil, fmt.Errorf("%w: give at most one of If-Match, ?set= and an old value", errInvalidPrecondition)
+	}
+}
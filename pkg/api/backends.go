@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// healthCheckTimeout bounds the ping of each backend in GET /backends
+const healthCheckTimeout = 2 * time.Second
+
+// BackendStatus describes a registered backend in GET /backends
+type BackendStatus struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HandleBackendsRequest lists the registered backends in registration order,
+// pinging them all concurrently
+func HandleBackendsRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+
+		names := unifiedCache.backends.Names()
+		statuses := make([]BackendStatus, len(names))
+		var wg sync.WaitGroup
+		for i, name := range names {
+			backend, _ := unifiedCache.backends.Get(name)
+			statuses[i] = BackendStatus{Name: name, Type: backendType(backend), Healthy: true}
+
+			wg.Add(1)
+			go func(status *BackendStatus, backend cache.Cache) {
+				defer wg.Done()
+				if err := cache.Ping(ctx, backend); err != nil {
+					status.Healthy, status.Error = false, err.Error()
+				}
+			}(&statuses[i], backend)
+		}
+		wg.Wait()
+
+		response, err := json.Marshal(statuses)
+		if err != nil {
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}
+}
+
+func backendType(backend cache.Cache) string {
+	switch backend.(type) {
+	case *cache.LRUCache:
+		return "lru"
+	case *cache.ShardedLRUCache:
+		return "sharded-lru"
+	case *cache.RedisCache:
+		return "redis"
+	case *cache.MemcachedCache:
+		return "memcached"
+	default:
+		return fmt.Sprintf("%T", backend)
+	}
+}
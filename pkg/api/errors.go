@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+var (
+	errInvalidCacheType    = errors.New("invalid cache type")
+	errInvalidWritePolicy  = errors.New("invalid write policy")
+	errInvalidTTL          = errors.New("invalid TTL")
+	errInvalidPrecondition = errors.New("invalid precondition")
+	errUnsupported         = errors.New("not supported by the cache")
+	errKeyExists           = errors.New("key already exists")
+	errInvalidBody         = errors.New("invalid request body")
+)
+
+// statusFromError maps cache errors to HTTP status codes
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, cache.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errInvalidCacheType), errors.Is(err, errInvalidWritePolicy), errors.Is(err, errInvalidTTL),
+		errors.Is(err, errInvalidPrecondition), errors.Is(err, errInvalidBody), errors.Is(err, cache.ErrInvalidKey):
+		return http.StatusBadRequest
+	case errors.Is(err, cache.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, errKeyExists):
+		return http.StatusConflict
+	case errors.Is(err, errUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, cache.ErrValueTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, cache.ErrTypeMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, cache.ErrBackendUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// setETag sets the ETag header of a response carrying value and returns the
+// ETag, or "" when the value has none
+func setETag(w http.ResponseWriter, value interface{}) string {
+	etag, err := cache.ETag(value)
+	if err != nil {
+		return ""
+	}
+	w.Header().Set("ETag", etag)
+	return etag
+}
+
+// notModified answers 304 Not Modified when the request's If-None-Match
+// names the ETag of value, and reports whether it did
+func notModified(w http.ResponseWriter, r *http.Request, value interface{}) bool {
+	etag := setETag(w, value)
+	header := r.Header.Get("If-None-Match")
+	if etag == "" || header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		// If-None-Match uses the weak comparison
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == cache.AnyETag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// requestIfMatch returns the If-Match precondition of a write, or "" when it
+// has none. Only a single strong ETag or "*" is accepted.
+func requestIfMatch(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if strings.Contains(header, ",") || strings.HasPrefix(header, "W/") {
+		return "", fmt.Errorf("%w: If-Match takes a single strong ETag", errInvalidPrecondition)
+	}
+	return header, nil
+}
+
+// ifMatch is the write condition of an If-Match precondition
+func ifMatch(etag string) writeCondition {
+	return func(ctx context.Context, backend cache.Cache, key string, value interface{}, ttl time.Duration) error {
+		conditional, ok := backend.(cache.ConditionalCache)
+		if !ok {
+			return fmt.Errorf("%w: conditional writes", errUnsupported)
+		}
+		return conditional.SetIfMatch(ctx, key, value, ttl, etag)
+	}
+}
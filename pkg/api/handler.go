@@ -1,9 +1,12 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path"
+	"strconv"
 
 	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
 
@@ -12,18 +15,93 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// UnifiedCache serves the REST API over the named backends of a registry
 type UnifiedCache struct {
-	InMemoryCache  cache.Cache
-	RedisCache     cache.Cache
-	MemcachedCache cache.Cache
+	backends *cache.Registry
+
+	tierOrder   []string
+	writePolicy WritePolicy
+	defaultTTL  time.Duration
+	minTTL      time.Duration
+	maxTTL      time.Duration
+	backfillTTL time.Duration
+	maxBody     int64
+	stats       map[string]*tierCounters
+	loads       cache.LoadGroup
+}
+
+// NewUnifiedCache registers the three standard backends as "inMemory",
+// "redis" and "memcached", skipping any that are nil
+func NewUnifiedCache(inMemoryCache, redisCache, memcachedCache cache.Cache, opts ...UnifiedOption) *UnifiedCache {
+	backends := cache.NewRegistry()
+	for _, backend := range []struct {
+		name  string
+		cache cache.Cache
+	}{
+		{"inMemory", inMemoryCache},
+		{"redis", redisCache},
+		{"memcached", memcachedCache},
+	} {
+		if backend.cache != nil {
+			backends.Register(backend.name, backend.cache)
+		}
+	}
+	return NewUnifiedCacheFromRegistry(backends, opts...)
 }
 
-func NewUnifiedCache(inMemoryCache, redisCache, memcachedCache cache.Cache) *UnifiedCache {
-	return &UnifiedCache{
-		InMemoryCache:  inMemoryCache,
-		RedisCache:     redisCache,
-		MemcachedCache: memcachedCache,
+// NewUnifiedCacheFromRegistry serves the backends of a registry, addressed by
+// their registered names. Tiered reads and writes use every backend in
+// registration order unless WithTierOrder says otherwise.
+func NewUnifiedCacheFromRegistry(backends *cache.Registry, opts ...UnifiedOption) *UnifiedCache {
+	u := &UnifiedCache{
+		backends:    backends,
+		tierOrder:   backends.Names(),
+		defaultTTL:  time.Minute,
+		backfillTTL: time.Minute,
+		maxBody:     DefaultMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(u)
 	}
+	u.stats = make(map[string]*tierCounters, len(u.tierOrder))
+	for _, tier := range u.tierOrder {
+		u.stats[tier] = &tierCounters{}
+	}
+	return u
+}
+
+// Backend returns the backend registered under name
+func (u *UnifiedCache) Backend(name string) (cache.Cache, bool) {
+	return u.backends.Get(name)
+}
+
+// GetOrLoad reads key through the tiers as GetTiered does, backfilling the
+// faster ones. When every tier misses, loader is called once for all
+// concurrent callers and its result is written to every tier.
+func (u *UnifiedCache) GetOrLoad(key string, loader cache.LoaderFunc, ttl time.Duration) (interface{}, error) {
+	return u.GetOrLoadCtx(context.Background(), key, loader, ttl)
+}
+
+// GetOrLoadCtx is GetOrLoad with ctx bounding the cache read. The shared load
+// is not cancelled with ctx, since other callers may be waiting on it.
+func (u *UnifiedCache) GetOrLoadCtx(ctx context.Context, key string, loader cache.LoaderFunc, ttl time.Duration) (interface{}, error) {
+	if value, err := u.GetTiered(ctx, key); err == nil {
+		return value, nil
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return u.loads.Do(key, func() (interface{}, error) {
+		// Another caller may have loaded the value since our read
+		if value, err := u.GetTiered(context.Background(), key); err == nil {
+			return value, nil
+		}
+		value, err := loader(key)
+		if err != nil {
+			return nil, err
+		}
+		_, err = setCacheValueInAllCaches(context.Background(), u, key, value, ttl)
+		return value, err
+	})
 }
 
 func HandleCacheRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
@@ -34,34 +112,69 @@ func HandleCacheRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
 
 		switch r.Method {
 		case "GET":
-			value, err := getCacheValue(unifiedCache, key, cacheType)
+			if cacheType == "" {
+				value, err := unifiedCache.GetTiered(r.Context(), key)
+				if err != nil {
+					http.Error(w, err.Error(), statusFromError(err))
+					return
+				}
+				if !notModified(w, r, value) {
+					writeValue(w, value)
+				}
+				return
+			}
+			value, err := getCacheValue(r.Context(), unifiedCache, key, cacheType)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusNotFound)
+				http.Error(w, err.Error(), statusFromError(err))
 				return
 			}
-			w.Write([]byte(value))
+			if !notModified(w, r, value) {
+				writeValue(w, value)
+			}
 		case "POST":
-			var requestBody map[string]interface{}
-			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-				http.Error(w, "Invalid request body", http.StatusBadRequest)
+			body, err := unifiedCache.readBody(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), statusFromError(err))
 				return
 			}
-			value, ok := requestBody["value"].(string)
-			if !ok {
-				http.Error(w, "Invalid value format", http.StatusBadRequest)
+			req, err := decodeWriteRequest(body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
-			ttl := time.Minute
-			err := setCacheValueInAllCaches(unifiedCache, key, value, ttl)
+			policy, err := unifiedCache.requestWritePolicy(r)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), statusFromError(err))
 				return
 			}
-			w.WriteHeader(http.StatusOK)
+			ttl, err := unifiedCache.requestTTL(r, req.body)
+			if err != nil {
+				http.Error(w, err.Error(), statusFromError(err))
+				return
+			}
+			writeValueToTiers(w, r, unifiedCache, policy, key, req.value, req.old, ttl)
+		case "PUT":
+			body, err := unifiedCache.readBody(w, r)
+			if err != nil {
+				http.Error(w, err.Error(), statusFromError(err))
+				return
+			}
+			value := blobOf(r, body)
+			policy, err := unifiedCache.requestWritePolicy(r)
+			if err != nil {
+				http.Error(w, err.Error(), statusFromError(err))
+				return
+			}
+			ttl, err := unifiedCache.requestTTL(r, nil)
+			if err != nil {
+				http.Error(w, err.Error(), statusFromError(err))
+				return
+			}
+			writeValueToTiers(w, r, unifiedCache, policy, key, value, nil, ttl)
 		case "DELETE":
-			err := deleteCacheValue(unifiedCache, key, cacheType)
+			err := deleteCacheValue(r.Context(), unifiedCache, key, cacheType)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusNotFound)
+				http.Error(w, err.Error(), statusFromError(err))
 				return
 			}
 			w.WriteHeader(http.StatusOK)
@@ -73,9 +186,14 @@ func HandleCacheRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
 
 func HandleGetAllCacheRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		allEntries, err := GetAllCacheEntries(unifiedCache)
+		if cacheType := r.URL.Query().Get("cache"); cacheType != "" {
+			handleScanRequest(w, r, unifiedCache, cacheType)
+			return
+		}
+
+		allEntries, err := GetAllCacheEntries(r.Context(), unifiedCache)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), statusFromError(err))
 			return
 		}
 		response, err := json.Marshal(allEntries)
@@ -88,90 +206,156 @@ func HandleGetAllCacheRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
 	}
 }
 
-func getCacheValue(unifiedCache *UnifiedCache, key string, cacheType string) (string, error) {
-	var value interface{}
-	var err error
+// HandleDeletePrefixRequest deletes every key starting with ?prefix= from the
+// backend named by ?cache=, which must be able to list its keys
+func HandleDeletePrefixRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backend, err := backendFor(unifiedCache, r.URL.Query().Get("cache"))
+		if err != nil {
+			http.Error(w, err.Error(), statusFromError(err))
+			return
+		}
+		lister, ok := backend.(cache.KeyLister)
+		if !ok {
+			http.Error(w, "Cache cannot delete by prefix", http.StatusNotImplemented)
+			return
+		}
 
-	switch cacheType {
-	case "inMemory":
-		value, err = unifiedCache.InMemoryCache.Get(key)
-	case "redis":
-		value, err = unifiedCache.RedisCache.Get(key)
-	case "memcached":
-		value, err = unifiedCache.MemcachedCache.Get(key)
-	default:
-		return "", fmt.Errorf("invalid cache type")
+		deleted, err := lister.DeletePrefix(r.URL.Query().Get("prefix"))
+		if err != nil {
+			http.Error(w, err.Error(), statusFromError(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"deleted": deleted})
 	}
+}
 
+// scanPage is the response of GET /cache?cache=<type>. Cursor is passed back
+// as ?cursor= to fetch the next page and is 0 once the scan is over.
+type scanPage struct {
+	Entries map[string]interface{} `json:"entries"`
+	Cursor  uint64                 `json:"cursor"`
+}
+
+// handleScanRequest returns one page of a single backend's entries. Backends
+// that cannot scan return all their entries in one page.
+func handleScanRequest(w http.ResponseWriter, r *http.Request, unifiedCache *UnifiedCache, cacheType string) {
+	backend, err := backendFor(unifiedCache, cacheType)
 	if err != nil {
-		return "", err
+		http.Error(w, err.Error(), statusFromError(err))
+		return
 	}
 
-	strValue, ok := value.(string)
-	if !ok {
-		return "", fmt.Errorf("value is not of type string")
+	query := r.URL.Query()
+	opts := cache.ScanOptions{Match: query.Get("match")}
+	if _, err := path.Match(opts.Match, ""); err != nil {
+		http.Error(w, "Invalid match pattern", http.StatusBadRequest)
+		return
 	}
-	return strValue, nil
-}
-
-func setCacheValueInAllCaches(unifiedCache *UnifiedCache, key, value string, ttl time.Duration) error {
-	if err := unifiedCache.InMemoryCache.Set(key, value, ttl); err != nil {
-		return fmt.Errorf("failed to set value in in-memory cache: %w", err)
+	if count := query.Get("count"); count != "" {
+		if opts.Count, err = strconv.Atoi(count); err != nil || opts.Count <= 0 {
+			http.Error(w, "Invalid count", http.StatusBadRequest)
+			return
+		}
+	}
+	var cursor uint64
+	if c := query.Get("cursor"); c != "" {
+		if cursor, err = strconv.ParseUint(c, 10, 64); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
 	}
 
-	if err := unifiedCache.RedisCache.Set(key, value, ttl); err != nil {
-		return fmt.Errorf("failed to set value in Redis cache: %w", err)
+	page := scanPage{}
+	if scanner, ok := backend.(cache.Scanner); ok {
+		page.Entries, page.Cursor, err = scanner.Scan(r.Context(), cursor, opts)
+	} else {
+		page.Entries, err = cache.GetAllContext(r.Context(), backend)
+		for key := range page.Entries {
+			if matched, _ := path.Match(opts.Match, key); opts.Match != "" && !matched {
+				delete(page.Entries, key)
+			}
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err))
+		return
 	}
 
-	if err := unifiedCache.MemcachedCache.Set(key, value, ttl); err != nil {
-		return fmt.Errorf("failed to set value in Memcached cache: %w", err)
+	response, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
 
-	return nil
+// writeValueToTiers writes a value under policy and the request's condition,
+// answering with the write report and the value's ETag. A non-nil old makes
+// the write a compare-and-swap.
+func writeValueToTiers(w http.ResponseWriter, r *http.Request, unifiedCache *UnifiedCache, policy WritePolicy, key string, value, old interface{}, ttl time.Duration) {
+	condition, err := requestCondition(r, old)
+	if err != nil {
+		http.Error(w, err.Error(), statusFromError(err))
+		return
+	}
+	report, err := unifiedCache.writeAllTiersWhen(r.Context(), policy, key, value, ttl, condition)
+	if err == nil {
+		setETag(w, value)
+	}
+	writeReport(w, report, err)
 }
 
-func deleteCacheValue(unifiedCache *UnifiedCache, key string, cacheType string) error {
-	switch cacheType {
-	case "inMemory":
-		return unifiedCache.InMemoryCache.Delete(key)
-	case "redis":
-		return unifiedCache.RedisCache.Delete(key)
-	case "memcached":
-		return unifiedCache.MemcachedCache.Delete(key)
-	default:
-		return fmt.Errorf("invalid cache type")
+func backendFor(unifiedCache *UnifiedCache, name string) (cache.Cache, error) {
+	if backend, found := unifiedCache.backends.Get(name); found {
+		return backend, nil
 	}
+	return nil, fmt.Errorf("%w: %q", errInvalidCacheType, name)
 }
 
-func GetAllCacheEntries(unifiedCache *UnifiedCache) (map[string]interface{}, error) {
-	allEntries := make(map[string]interface{})
+// primary returns the first tier
+func (u *UnifiedCache) primary() (cache.Cache, error) {
+	if len(u.tierOrder) == 0 {
+		return nil, fmt.Errorf("%w: no backends configured", errInvalidCacheType)
+	}
+	return backendFor(u, u.tierOrder[0])
+}
 
-	if unifiedCache.InMemoryCache != nil {
-		lruEntries, err := unifiedCache.InMemoryCache.GetAll()
-		if err != nil {
-			return nil, err
-		}
-		for k, v := range lruEntries {
-			allEntries[k] = v
-		}
+func getCacheValue(ctx context.Context, unifiedCache *UnifiedCache, key string, cacheType string) (interface{}, error) {
+	backend, err := backendFor(unifiedCache, cacheType)
+	if err != nil {
+		return nil, err
 	}
+	return cache.GetContext(ctx, backend, key)
+}
 
-	if unifiedCache.RedisCache != nil {
-		redisEntries, err := unifiedCache.RedisCache.GetAll()
-		if err != nil {
-			return nil, err
-		}
-		for k, v := range redisEntries {
-			allEntries[k] = v
-		}
+// setCacheValueInAllCaches writes to every tier under the cache's write policy
+func setCacheValueInAllCaches(ctx context.Context, unifiedCache *UnifiedCache, key string, value interface{}, ttl time.Duration) (WriteReport, error) {
+	return unifiedCache.writeAllTiers(ctx, unifiedCache.writePolicy, key, value, ttl)
+}
+
+func deleteCacheValue(ctx context.Context, unifiedCache *UnifiedCache, key string, cacheType string) error {
+	backend, err := backendFor(unifiedCache, cacheType)
+	if err != nil {
+		return err
 	}
+	return cache.DeleteContext(ctx, backend, key)
+}
+
+// GetAllCacheEntries merges the entries of every backend. When backends hold
+// the same key, the one registered last wins.
+func GetAllCacheEntries(ctx context.Context, unifiedCache *UnifiedCache) (map[string]interface{}, error) {
+	allEntries := make(map[string]interface{})
 
-	if unifiedCache.MemcachedCache != nil {
-		memcachedEntries, err := unifiedCache.MemcachedCache.GetAll()
+	for _, name := range unifiedCache.backends.Names() {
+		backend, _ := unifiedCache.backends.Get(name)
+		entries, err := cache.GetAllContext(ctx, backend)
 		if err != nil {
 			return nil, err
 		}
-		for k, v := range memcachedEntries {
+		for k, v := range entries {
 			allEntries[k] = v
 		}
 	}
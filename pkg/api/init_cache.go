@@ -3,25 +3,131 @@ package api
 import (
 	"fmt"
 
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/config"
 	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
 )
 
-func InitCache() (*UnifiedCache, error) {
+func InitCache(cfg config.CacheConfig) (*UnifiedCache, error) {
+	backends := cache.NewRegistry()
+	for _, backendCfg := range cfg.BackendList() {
+		backend, err := newBackend(backendCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s cache %q: %w", backendCfg.Type, backendCfg.Name, err)
+		}
+		if err := backends.Register(backendCfg.Name, backend); err != nil {
+			return nil, err
+		}
+	}
 
-	inMemoryCache := cache.NewLRUCache(5)
-	if inMemoryCache == nil {
-		return nil, fmt.Errorf("failed to initialize in-memory cache")
+	var opts []UnifiedOption
+	if cfg.DefaultTTL > 0 {
+		opts = append(opts, WithDefaultTTL(cfg.DefaultTTL), WithBackfillTTL(cfg.DefaultTTL))
+	}
+	if cfg.MaxTTL > 0 && cfg.MinTTL > cfg.MaxTTL {
+		return nil, fmt.Errorf("minimum TTL %v exceeds maximum TTL %v", cfg.MinTTL, cfg.MaxTTL)
+	}
+	opts = append(opts, WithTTLLimits(cfg.MinTTL, cfg.MaxTTL))
+	if cfg.WritePolicy != "" {
+		policy, err := ParseWritePolicy(cfg.WritePolicy)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithWritePolicy(policy))
+	}
+	if len(cfg.TierOrder) > 0 {
+		opts = append(opts, WithTierOrder(cfg.TierOrder...))
+	}
+	if cfg.MaxBodyBytes > 0 {
+		opts = append(opts, WithMaxBodyBytes(cfg.MaxBodyBytes))
+	}
+	unifiedCache := NewUnifiedCacheFromRegistry(backends, opts...)
+	for _, tier := range unifiedCache.tierOrder {
+		if _, err := backendFor(unifiedCache, tier); err != nil {
+			return nil, fmt.Errorf("%w in tier order", err)
+		}
 	}
+	return unifiedCache, nil
+}
 
-	redisCache, err := cache.NewRedisCache("localhost:6379")
+func newBackend(cfg config.BackendConfig) (cache.Cache, error) {
+	switch cfg.Type {
+	case "lru":
+		return cache.NewLRUCache(cfg.MaxLRUSize), nil
+	case "redis":
+		return newRedisBackend(cfg)
+	case "memcached":
+		return newMemcachedBackend(cfg)
+	default:
+		return nil, fmt.Errorf("unknown backend type %q", cfg.Type)
+	}
+}
+
+func newRedisBackend(cfg config.BackendConfig) (cache.Cache, error) {
+	codec, err := cache.ParseCodec(cfg.Codec, cfg.CompressAbove)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Redis cache: %w", err)
+		return nil, err
 	}
+	redisCache, err := cache.NewRedisCacheWithOptions(redisOptions(cfg))
+	if err != nil {
+		return nil, err
+	}
+	redisCache.SetTimeout(cfg.RedisTimeout)
+	redisCache.SetCodec(codec)
+	return redisCache, nil
+}
 
-	memcachedCache, err := cache.NewMemcachedCache("localhost:11211")
+func newMemcachedBackend(cfg config.BackendConfig) (cache.Cache, error) {
+	servers := make([]cache.MemcachedServer, len(cfg.MemcachedServers))
+	for i, s := range cfg.MemcachedServers {
+		var err error
+		if servers[i], err = cache.ParseMemcachedServer(s); err != nil {
+			return nil, err
+		}
+	}
+	codec, err := cache.ParseCodec(cfg.Codec, cfg.CompressAbove)
+	if err != nil {
+		return nil, err
+	}
+	opts := []cache.MemcachedOption{cache.WithCodec(codec)}
+	if cfg.MemcachedKeyIndex {
+		opts = append(opts, cache.WithKeyIndex())
+	}
+	if cfg.MemcachedDeadRetry > 0 {
+		opts = append(opts, cache.WithDeadRetry(cfg.MemcachedDeadRetry))
+	}
+	memcachedCache, err := cache.NewMemcachedCluster(servers, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Memcached cache: %w", err)
+		return nil, err
+	}
+	if cfg.MemcachedTimeout > 0 {
+		memcachedCache.SetTimeout(cfg.MemcachedTimeout)
 	}
+	return memcachedCache, nil
+}
 
-	return NewUnifiedCache(inMemoryCache, redisCache, memcachedCache), nil
+func redisOptions(cfg config.BackendConfig) cache.RedisOptions {
+	return cache.RedisOptions{
+		Addr:                  cfg.RedisAddr,
+		Addrs:                 cfg.Redis.Addrs,
+		MasterName:            cfg.Redis.MasterName,
+		SentinelUsername:      cfg.Redis.SentinelUsername,
+		SentinelPassword:      cfg.Redis.SentinelPassword,
+		Cluster:               cfg.Redis.Cluster,
+		ReadOnly:              cfg.Redis.ReadOnly,
+		Username:              cfg.Redis.Username,
+		Password:              cfg.Redis.Password,
+		DB:                    cfg.Redis.DB,
+		TLS:                   cfg.Redis.TLS,
+		TLSCAFile:             cfg.Redis.TLSCAFile,
+		TLSServerName:         cfg.Redis.TLSServerName,
+		TLSInsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		PoolSize:              cfg.Redis.PoolSize,
+		MinIdleConns:          cfg.Redis.MinIdleConns,
+		DialTimeout:           cfg.Redis.DialTimeout,
+		ReadTimeout:           cfg.Redis.ReadTimeout,
+		WriteTimeout:          cfg.Redis.WriteTimeout,
+		MaxRetries:            cfg.Redis.MaxRetries,
+		MinRetryBackoff:       cfg.Redis.MinRetryBackoff,
+		MaxRetryBackoff:       cfg.Redis.MaxRetryBackoff,
+	}
 }
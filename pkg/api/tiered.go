@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// UnifiedOption configures a UnifiedCache
+type UnifiedOption func(*UnifiedCache)
+
+// WithTierOrder sets the backends tiered reads and writes use and their
+// order, fastest first, using the same names as ?cache=
+func WithTierOrder(tiers ...string) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.tierOrder = tiers
+	}
+}
+
+// WithBackfillTTL sets the TTL given to backfilled entries when the tier
+// that hit cannot report the entry's remaining TTL. The default is one
+// minute.
+func WithBackfillTTL(ttl time.Duration) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.backfillTTL = ttl
+	}
+}
+
+// TierStats counts how the reads of one tier went
+type TierStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Errors    uint64 `json:"errors"`
+	Backfills uint64 `json:"backfills"`
+}
+
+type tierCounters struct {
+	hits, misses, errors, backfills atomic.Uint64
+}
+
+// GetTiered reads key from each tier in order and returns the first hit.
+// The faster tiers that missed are backfilled with the entry's remaining
+// TTL. A tier that fails counts as a miss, so the read only fails when no
+// tier could answer.
+func (u *UnifiedCache) GetTiered(ctx context.Context, key string) (interface{}, error) {
+	var lastErr error
+	missed := false
+	for i, tier := range u.tierOrder {
+		backend, err := backendFor(u, tier)
+		if err != nil {
+			return nil, err
+		}
+		counters := u.counters(tier)
+
+		value, ttl, knownTTL, err := cache.GetWithTTL(ctx, backend, key)
+		switch {
+		case err == nil:
+			counters.hits.Add(1)
+			if !knownTTL {
+				ttl = u.backfillTTL
+			}
+			u.backfill(ctx, u.tierOrder[:i], key, value, ttl)
+			return value, nil
+		case errors.Is(err, cache.ErrNotFound):
+			counters.misses.Add(1)
+			missed = true
+		default:
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			counters.errors.Add(1)
+			lastErr = err
+		}
+	}
+	if missed || lastErr == nil {
+		return nil, cache.ErrNotFound
+	}
+	return nil, lastErr
+}
+
+// backfill copies a value found in a slower tier into the faster ones. It is
+// best effort: a tier that rejects the value is skipped. A ttl of 0 copies an
+// entry without expiry.
+func (u *UnifiedCache) backfill(ctx context.Context, tiers []string, key string, value interface{}, ttl time.Duration) {
+	for _, tier := range tiers {
+		backend, err := backendFor(u, tier)
+		if err != nil {
+			continue
+		}
+		if cache.SetContext(ctx, backend, key, value, ttl) == nil {
+			u.counters(tier).backfills.Add(1)
+		}
+	}
+}
+
+// Stats returns the read statistics of every tier
+func (u *UnifiedCache) Stats() map[string]TierStats {
+	stats := make(map[string]TierStats, len(u.stats))
+	for tier, counters := range u.stats {
+		stats[tier] = TierStats{
+			Hits:      counters.hits.Load(),
+			Misses:    counters.misses.Load(),
+			Errors:    counters.errors.Load(),
+			Backfills: counters.backfills.Load(),
+		}
+	}
+	return stats
+}
+
+func (u *UnifiedCache) counters(tier string) *tierCounters {
+	return u.stats[tier]
+}
+
+// HandleStatsRequest returns the per-tier read statistics
+func HandleStatsRequest(unifiedCache *UnifiedCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := json.Marshal(unifiedCache.Stats())
+		if err != nil {
+			http.Error(w, "Error encoding response", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// NoExpiryTTL is the TTL value, in the body or the X-Cache-TTL header, that
+// stores an entry without expiry
+const NoExpiryTTL = "never"
+
+// Headers accepted by POST /cache/{key} when the body has no TTL
+const (
+	ttlHeader       = "X-Cache-TTL"
+	expiresAtHeader = "X-Cache-Expires-At"
+)
+
+// WithDefaultTTL sets the TTL of writes that do not give one. The default is
+// one minute.
+func WithDefaultTTL(ttl time.Duration) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.defaultTTL = ttl
+	}
+}
+
+// WithTTLLimits bounds the TTL a client may ask for. Zero leaves a bound
+// open; a maximum also rules out entries without expiry.
+func WithTTLLimits(min, max time.Duration) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.minTTL, u.maxTTL = min, max
+	}
+}
+
+// requestTTL works out the TTL of a write from the "ttl" or "expiresAt"
+// fields of the body, falling back to the X-Cache-TTL and X-Cache-Expires-At
+// headers and then to the default TTL. A TTL is a duration string such as
+// "90s", a number of seconds, or NoExpiryTTL; an expiry is an RFC 3339 time
+// or a Unix timestamp in seconds. The result is 0 for no expiry.
+func (u *UnifiedCache) requestTTL(r *http.Request, body map[string]interface{}) (time.Duration, error) {
+	now := time.Now()
+	ttlValue, hasTTL := body["ttl"]
+	expiresValue, hasExpires := body["expiresAt"]
+	if !hasTTL && !hasExpires {
+		if header := r.Header.Get(ttlHeader); header != "" {
+			ttlValue, hasTTL = header, true
+		}
+		if header := r.Header.Get(expiresAtHeader); header != "" {
+			expiresValue, hasExpires = header, true
+		}
+	}
+
+	var ttl time.Duration
+	switch {
+	case hasTTL && hasExpires:
+		return 0, fmt.Errorf("%w: give either a TTL or an expiry time, not both", errInvalidTTL)
+	case hasTTL:
+		var err error
+		if ttl, err = parseTTL(ttlValue); err != nil {
+			return 0, err
+		}
+	case hasExpires:
+		expiresAt, err := parseExpiry(expiresValue)
+		if err != nil {
+			return 0, err
+		}
+		if ttl = expiresAt.Sub(now); ttl <= 0 {
+			return 0, fmt.Errorf("%w: expiry time %s is in the past", errInvalidTTL, expiresAt.Format(time.RFC3339))
+		}
+	default:
+		return u.defaultTTL, nil
+	}
+
+	if u.minTTL > 0 && ttl > 0 && ttl < u.minTTL {
+		return 0, fmt.Errorf("%w: %v is below the minimum of %v", errInvalidTTL, ttl, u.minTTL)
+	}
+	if u.maxTTL > 0 && (ttl == 0 || ttl > u.maxTTL) {
+		return 0, fmt.Errorf("%w: TTL exceeds the maximum of %v", errInvalidTTL, u.maxTTL)
+	}
+	return ttl, nil
+}
+
+func parseTTL(value interface{}) (time.Duration, error) {
+	var ttl time.Duration
+	switch v := value.(type) {
+	case float64:
+		ttl = time.Duration(v * float64(time.Second))
+	case string:
+		if v == NoExpiryTTL {
+			return 0, nil
+		}
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			ttl = time.Duration(seconds * float64(time.Second))
+		} else if ttl, err = time.ParseDuration(v); err != nil {
+			return 0, fmt.Errorf("%w: %q is not a duration, a number of seconds or %q", errInvalidTTL, v, NoExpiryTTL)
+		}
+	default:
+		return 0, fmt.Errorf("%w: unsupported TTL %v", errInvalidTTL, value)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("%w: TTL must be positive, use %q for no expiry", errInvalidTTL, NoExpiryTTL)
+	}
+	return ttl, nil
+}
+
+func parseExpiry(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	case string:
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(seconds, 0), nil
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("%w: %q is not an RFC 3339 time or a Unix timestamp", errInvalidTTL, v)
+	default:
+		return time.Time{}, fmt.Errorf("%w: unsupported expiry time %v", errInvalidTTL, value)
+	}
+}
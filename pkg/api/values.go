@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// DefaultMaxBodyBytes is the largest POST or PUT body a UnifiedCache reads
+// unless WithMaxBodyBytes says otherwise
+const DefaultMaxBodyBytes = 1 << 20
+
+// WithMaxBodyBytes limits the size of POST and PUT request bodies. Larger
+// bodies are refused with 413 Request Entity Too Large. A limit <= 0 removes
+// it.
+func WithMaxBodyBytes(limit int64) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.maxBody = limit
+	}
+}
+
+// readBody reads the body of a write, up to the configured limit
+func (u *UnifiedCache) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body := r.Body
+	if u.maxBody > 0 {
+		body = http.MaxBytesReader(w, r.Body, u.maxBody)
+	}
+	data, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, fmt.Errorf("%w: request body exceeds %d bytes", cache.ErrValueTooLarge, tooLarge.Limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidBody, err)
+	}
+	return data, nil
+}
+
+// writeRequest is the JSON body of a write
+type writeRequest struct {
+	// body holds every field, for the TTL fields
+	body  map[string]interface{}
+	value interface{}
+	// old is the value a compare-and-swap expects, or nil
+	old interface{}
+}
+
+// decodeWriteRequest decodes the JSON body of a write. The "value" and "old"
+// fields may be any JSON value: strings are stored as plain strings, as they
+// always were, and anything else as its compacted JSON text.
+func decodeWriteRequest(data []byte) (writeRequest, error) {
+	var req writeRequest
+	var fields struct {
+		Value json.RawMessage `json:"value"`
+		Old   json.RawMessage `json:"old"`
+	}
+	if json.Unmarshal(data, &req.body) != nil || json.Unmarshal(data, &fields) != nil {
+		return req, errors.New("Invalid request body")
+	}
+	if req.value = jsonValue(fields.Value); req.value == nil {
+		return req, errors.New("Invalid value format")
+	}
+	req.old = jsonValue(fields.Old)
+	return req, nil
+}
+
+// jsonValue converts a JSON value from a request into the value stored, or
+// nil when it is missing or null
+func jsonValue(raw json.RawMessage) interface{} {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var str string
+	if json.Unmarshal(raw, &str) == nil {
+		return str
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, raw) != nil {
+		return nil
+	}
+	return json.RawMessage(compact.Bytes())
+}
+
+// blobOf keeps the body of a PUT as-is, with its content type
+func blobOf(r *http.Request, data []byte) cache.Blob {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return cache.Blob{ContentType: contentType, Data: data}
+}
+
+// writeValue writes a cached value as the response body. Strings are written
+// as plain text, Blobs with the content type they were stored with, JSON
+// values with an application/json content type and byte slices as
+// application/octet-stream.
+func writeValue(w http.ResponseWriter, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		w.Write([]byte(v))
+	case cache.Blob:
+		w.Header().Set("Content-Type", v.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(v.Data)))
+		w.Write(v.Data)
+	case []byte:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(v)
+	case json.RawMessage:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			err = cache.ErrTypeMismatch
+			http.Error(w, err.Error(), statusFromError(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
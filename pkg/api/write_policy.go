@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// WritePolicy decides how a write to every tier handles tiers that fail
+type WritePolicy int
+
+const (
+	// WriteAll succeeds only if every tier stored the value. Otherwise the
+	// tiers that did are compensated by deleting the key, so no tier is left
+	// holding the new value alone; the previous value is not restored.
+	WriteAll WritePolicy = iota
+	// WriteBestEffort succeeds if any tier stored the value
+	WriteBestEffort
+	// WriteQuorum succeeds if a majority of the tiers stored the value.
+	// Tiers that did keep it even when the quorum is missed.
+	WriteQuorum
+	// WritePrimary writes the first tier synchronously and the others in the
+	// background once the primary succeeded
+	WritePrimary
+)
+
+var writePolicyNames = map[WritePolicy]string{
+	WriteAll:        "all",
+	WriteBestEffort: "best-effort",
+	WriteQuorum:     "quorum",
+	WritePrimary:    "primary",
+}
+
+func (p WritePolicy) String() string {
+	if name, found := writePolicyNames[p]; found {
+		return name
+	}
+	return fmt.Sprintf("WritePolicy(%d)", int(p))
+}
+
+// ParseWritePolicy parses a policy name as accepted by ?write=
+func ParseWritePolicy(name string) (WritePolicy, error) {
+	for policy, policyName := range writePolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", errInvalidWritePolicy, name)
+}
+
+// requestWritePolicy returns the policy named by ?write=, or the default
+func (u *UnifiedCache) requestWritePolicy(r *http.Request) (WritePolicy, error) {
+	if name := r.URL.Query().Get("write"); name != "" {
+		return ParseWritePolicy(name)
+	}
+	return u.writePolicy, nil
+}
+
+// writeReport answers a write with its report, failing with the status of
+// err when the write did not meet its policy
+func writeReport(w http.ResponseWriter, report WriteReport, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = statusFromError(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// WithWritePolicy sets the policy of writes that do not pick one with
+// ?write=. The default is WriteAll.
+func WithWritePolicy(policy WritePolicy) UnifiedOption {
+	return func(u *UnifiedCache) {
+		u.writePolicy = policy
+	}
+}
+
+// TierResult is the outcome of a write in one tier
+type TierResult struct {
+	Tier  string `json:"tier"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	// RolledBack is set when the write succeeded but was undone because the
+	// policy was not met
+	RolledBack bool `json:"rolledBack,omitempty"`
+	// Pending is set for tiers written in the background
+	Pending bool `json:"pending,omitempty"`
+}
+
+// WriteReport describes a write to every tier
+type WriteReport struct {
+	Policy string       `json:"policy"`
+	Tiers  []TierResult `json:"tiers"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// writeAllTiers stores the value in every tier under policy. The report is
+// complete even when an error is returned.
+func (u *UnifiedCache) writeAllTiers(ctx context.Context, policy WritePolicy, key string, value interface{}, ttl time.Duration) (WriteReport, error) {
+	return u.writeAllTiersWhen(ctx, policy, key, value, ttl, nil)
+}
+
+// writeCondition writes a value to a backend only if a condition holds,
+// atomically, failing when it does not
+type writeCondition func(ctx context.Context, backend cache.Cache, key string, value interface{}, ttl time.Duration) error
+
+// writeAllTiersWhen is writeAllTiers with a condition, which is checked
+// atomically against the first tier before the others are written. A nil
+// condition writes unconditionally.
+func (u *UnifiedCache) writeAllTiersWhen(ctx context.Context, policy WritePolicy, key string, value interface{}, ttl time.Duration, condition writeCondition) (WriteReport, error) {
+	report := WriteReport{Policy: policy.String()}
+	tiers := u.tierOrder
+	if policy == WritePrimary {
+		tiers = tiers[:min(1, len(tiers))]
+	}
+
+	var errs []error
+	if condition == nil {
+		errs = u.setTiers(ctx, tiers, key, value, ttl)
+	} else {
+		var err error
+		if errs, err = u.setTiersWhen(ctx, tiers, key, value, ttl, condition); err != nil {
+			if len(tiers) > 0 {
+				report.Tiers = []TierResult{{Tier: tiers[0], Error: err.Error()}}
+			}
+			report.Error = err.Error()
+			return report, err
+		}
+	}
+	succeeded := 0
+	var firstErr error
+	for i, tier := range tiers {
+		result := TierResult{Tier: tier, OK: errs[i] == nil}
+		if errs[i] != nil {
+			result.Error = errs[i].Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to set value in %s cache: %w", tier, errs[i])
+			}
+		} else {
+			succeeded++
+		}
+		report.Tiers = append(report.Tiers, result)
+	}
+
+	var err error
+	switch policy {
+	case WriteAll:
+		if firstErr != nil {
+			u.rollback(&report, key)
+			err = firstErr
+		}
+	case WriteBestEffort:
+		if succeeded == 0 {
+			err = firstErr
+		}
+	case WriteQuorum:
+		if quorum := len(tiers)/2 + 1; succeeded < quorum {
+			err = fmt.Errorf("wrote %d of %d tiers, quorum is %d: %w", succeeded, len(tiers), quorum, firstErr)
+		}
+	case WritePrimary:
+		if firstErr != nil {
+			err = firstErr
+		} else {
+			for _, tier := range u.tierOrder[len(tiers):] {
+				report.Tiers = append(report.Tiers, TierResult{Tier: tier, Pending: true})
+			}
+			go u.fanOut(u.tierOrder[len(tiers):], key, value, ttl)
+		}
+	default:
+		err = fmt.Errorf("%w: %v", errInvalidWritePolicy, policy)
+	}
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
+
+// setTiers writes to the tiers concurrently and returns their errors in order
+func (u *UnifiedCache) setTiers(ctx context.Context, tiers []string, key string, value interface{}, ttl time.Duration) []error {
+	errs := make([]error, len(tiers))
+	var wg sync.WaitGroup
+	for i, tier := range tiers {
+		backend, err := backendFor(u, tier)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		wg.Add(1)
+		go func(i int, backend cache.Cache) {
+			defer wg.Done()
+			errs[i] = cache.SetContext(ctx, backend, key, value, ttl)
+		}(i, backend)
+	}
+	wg.Wait()
+	return errs
+}
+
+// setTiersWhen writes the first tier under condition, and then the other
+// tiers. The error is set, and no tier written, when the condition fails.
+func (u *UnifiedCache) setTiersWhen(ctx context.Context, tiers []string, key string, value interface{}, ttl time.Duration, condition writeCondition) ([]error, error) {
+	if len(tiers) == 0 {
+		_, err := u.primary()
+		return nil, err
+	}
+	backend, err := backendFor(u, tiers[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := condition(ctx, backend, key, value, ttl); err != nil {
+		return nil, fmt.Errorf("failed to set value in %s cache: %w", tiers[0], err)
+	}
+	return append([]error{nil}, u.setTiers(ctx, tiers[1:], key, value, ttl)...), nil
+}
+
+// rollback deletes key from the tiers the report marks as written. It runs
+// without the request's context so that a canceled request still cleans up.
+func (u *UnifiedCache) rollback(report *WriteReport, key string) {
+	for i := range report.Tiers {
+		result := &report.Tiers[i]
+		if !result.OK {
+			continue
+		}
+		backend, _ := backendFor(u, result.Tier)
+		err := cache.DeleteContext(context.Background(), backend, key)
+		if err != nil && !errors.Is(err, cache.ErrNotFound) {
+			log.Printf("api: failed to roll back %q in %s cache: %v", key, result.Tier, err)
+			continue
+		}
+		result.RolledBack = true
+	}
+}
+
+// fanOut writes to the secondary tiers after a WritePrimary write
+func (u *UnifiedCache) fanOut(tiers []string, key string, value interface{}, ttl time.Duration) {
+	for i, err := range u.setTiers(context.Background(), tiers, key, value, ttl) {
+		if err != nil {
+			log.Printf("api: failed to set %q in %s cache: %v", key, tiers[i], err)
+		}
+	}
+}
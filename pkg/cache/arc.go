@@ -0,0 +1,94 @@
+package cache
+
+import "container/list"
+
+// arcPolicy implements Adaptive Replacement Cache (Megiddo & Modha). Resident
+// keys live in t1 (seen once recently) or t2 (seen at least twice); b1 and b2
+// remember keys recently evicted from each and steer the target size p of t1.
+type arcPolicy struct {
+	capacity int
+	p        int
+
+	t1, t2, b1, b2 *list.List
+	entries        segments
+}
+
+// NewARCPolicy returns an Adaptive Replacement Cache Policy
+func NewARCPolicy(capacity int) Policy {
+	return &arcPolicy{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		entries:  make(segments),
+	}
+}
+
+func (p *arcPolicy) Add(key string) {
+	switch p.entries.of(key) {
+	case p.b1:
+		p.p = min(p.size(), p.p+max(p.b2.Len()/max(p.b1.Len(), 1), 1))
+		p.entries.unlink(key)
+		p.entries.push(p.t2, key)
+	case p.b2:
+		p.p = max(0, p.p-max(p.b1.Len()/max(p.b2.Len(), 1), 1))
+		p.entries.unlink(key)
+		p.entries.push(p.t2, key)
+	case nil:
+		p.entries.push(p.t1, key)
+	}
+	p.trimGhosts()
+}
+
+func (p *arcPolicy) Touch(key string) {
+	if in := p.entries.of(key); in == p.t1 || in == p.t2 {
+		p.entries.unlink(key)
+		p.entries.push(p.t2, key)
+	}
+}
+
+func (p *arcPolicy) Remove(key string) {
+	p.entries.unlink(key)
+}
+
+func (p *arcPolicy) Evict(key string) {
+	switch p.entries.unlink(key) {
+	case p.t1:
+		p.entries.push(p.b1, key)
+	case p.t2:
+		p.entries.push(p.b2, key)
+	}
+	p.trimGhosts()
+}
+
+func (p *arcPolicy) Victim(incoming string) (string, bool) {
+	inB2 := p.entries.of(incoming) == p.b2
+	if p.t1.Len() > 0 && (p.t1.Len() > p.p || (inB2 && p.t1.Len() == p.p) || p.t2.Len() == 0) {
+		if key, ok := backExcept(p.t1, incoming); ok {
+			return key, true
+		}
+	}
+	if key, ok := backExcept(p.t2, incoming); ok {
+		return key, true
+	}
+	return backExcept(p.t1, incoming)
+}
+
+// size is the cache size c of the ARC paper. Without an entry capacity the
+// current number of resident keys stands in for it.
+func (p *arcPolicy) size() int {
+	return max(p.capacity, p.t1.Len()+p.t2.Len())
+}
+
+func (p *arcPolicy) trimGhosts() {
+	c := p.size()
+	for p.b1.Len() > 0 && p.t1.Len()+p.b1.Len() > c {
+		key, _ := back(p.b1)
+		p.entries.unlink(key)
+	}
+	for p.b2.Len() > 0 && p.t1.Len()+p.t2.Len()+p.b1.Len()+p.b2.Len() > 2*c {
+		key, _ := back(p.b2)
+		p.entries.unlink(key)
+	}
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AtomicCache is implemented by backends whose conditional writes are atomic
+// with respect to other writers, for create-only-if-absent and safe
+// read-modify-write. Each method reports whether it stored the value.
+type AtomicCache interface {
+	// SetNX stores value only if key is not set
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	// SetXX stores value only if key is set
+	SetXX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	// CompareAndSwap stores new only if key holds a value equal to old.
+	// Values are equal when they have the same ETag.
+	CompareAndSwap(ctx context.Context, key string, old, new interface{}, ttl time.Duration) (bool, error)
+}
+
+// compareAndSwap implements CompareAndSwap on top of SetIfMatch
+func compareAndSwap(ctx context.Context, c ConditionalCache, key string, old, new interface{}, ttl time.Duration) (bool, error) {
+	etag, err := ETag(old)
+	if err != nil {
+		return false, err
+	}
+	err = c.SetIfMatch(ctx, key, new, ttl, etag)
+	return err == nil, ignoreMismatch(err)
+}
+
+// ignoreMismatch turns the ErrVersionMismatch of a refused write into a
+// plain false
+func ignoreMismatch(err error) error {
+	if errors.Is(err, ErrVersionMismatch) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Codec converts values to and from the bytes remote backends store. Every
+// codec writes a header naming how the value was encoded, so Unmarshal reads
+// values written by any codec and a backend's codec can be changed without
+// flushing it.
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// Blob is an opaque value stored together with its media type, such as an
+// image or a protobuf message cached over HTTP
+type Blob struct {
+	ContentType string `json:"contentType"`
+	Data        []byte `json:"data"`
+}
+
+// Encoded values are stored in an envelope: valueMagic, a kind byte and the
+// payload. Strings are stored as-is so other clients can still read them;
+// valueMagic starts with a byte that never begins valid UTF-8 text, and
+// strings that happen to start with it are enveloped too.
+const valueMagic = "\xffCV"
+
+const (
+	valueString byte = iota + 1
+	valueBytes
+	valueJSON
+	valueBlob
+	valueGob
+	// valueGzip holds another encoded value, gzipped
+	valueGzip
+)
+
+// JSONCodec is the default codec. Strings and byte slices are kept as they
+// are, json.RawMessage values keep their exact text, Blobs keep their content
+// type, and anything else is encoded as JSON and read back as
+// json.RawMessage.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(value interface{}) ([]byte, error) {
+	if data, ok := marshalRaw(value); ok {
+		return data, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+	}
+	return envelope(valueJSON, data), nil
+}
+
+func (JSONCodec) Unmarshal(data []byte) (interface{}, error) {
+	return UnmarshalValue(data)
+}
+
+// GobCodec is JSONCodec with gob in place of JSON, so other values come back
+// with their Go type. Concrete types stored behind interface{} must be
+// registered with gob.Register.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(value interface{}) ([]byte, error) {
+	if data, ok := marshalRaw(value); ok {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+	}
+	return envelope(valueGob, buf.Bytes()), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	return UnmarshalValue(data)
+}
+
+// RawCodec only stores strings, byte slices, json.RawMessage values and
+// Blobs, and rejects other values with ErrTypeMismatch
+type RawCodec struct{}
+
+func (RawCodec) Marshal(value interface{}) ([]byte, error) {
+	if data, ok := marshalRaw(value); ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: raw codec cannot store %T", ErrTypeMismatch, value)
+}
+
+func (RawCodec) Unmarshal(data []byte) (interface{}, error) {
+	return UnmarshalValue(data)
+}
+
+// Compress wraps codec so encoded values longer than threshold bytes are
+// gzipped, when that makes them smaller
+func Compress(codec Codec, threshold int) Codec {
+	return compressCodec{codec: codec, threshold: threshold}
+}
+
+type compressCodec struct {
+	codec     Codec
+	threshold int
+}
+
+func (c compressCodec) Marshal(value interface{}) ([]byte, error) {
+	data, err := c.codec.Marshal(value)
+	if err != nil || len(data) <= c.threshold {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(valueMagic)
+	buf.WriteByte(valueGzip)
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if buf.Len() >= len(data) {
+		return data, nil
+	}
+	return buf.Bytes(), nil
+}
+
+func (c compressCodec) Unmarshal(data []byte) (interface{}, error) {
+	return c.codec.Unmarshal(data)
+}
+
+// ParseCodec returns the codec called name: "json" (the default when name
+// is empty), "gob" or "raw". A compressAbove above zero gzips encoded values
+// longer than that many bytes.
+func ParseCodec(name string, compressAbove int) (Codec, error) {
+	var codec Codec
+	switch name {
+	case "", "json":
+		codec = JSONCodec{}
+	case "gob":
+		codec = GobCodec{}
+	case "raw":
+		codec = RawCodec{}
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+	if compressAbove > 0 {
+		codec = Compress(codec, compressAbove)
+	}
+	return codec, nil
+}
+
+// MarshalValue encodes value with JSONCodec
+func MarshalValue(value interface{}) ([]byte, error) {
+	return JSONCodec{}.Marshal(value)
+}
+
+// UnmarshalValue decodes a value written by any codec. Values without an
+// envelope, such as those written by other clients, are returned as strings.
+func UnmarshalValue(data []byte) (interface{}, error) {
+	return unmarshalValue(data, false)
+}
+
+func unmarshalValue(data []byte, decompressed bool) (interface{}, error) {
+	if !bytes.HasPrefix(data, []byte(valueMagic)) {
+		return string(data), nil
+	}
+	if len(data) < len(valueMagic)+1 {
+		return nil, fmt.Errorf("%w: truncated value envelope", ErrTypeMismatch)
+	}
+
+	payload := data[len(valueMagic)+1:]
+	switch kind := data[len(valueMagic)]; kind {
+	case valueString:
+		return string(payload), nil
+	case valueBytes:
+		return append([]byte(nil), payload...), nil
+	case valueJSON:
+		return json.RawMessage(append([]byte(nil), payload...)), nil
+	case valueBlob:
+		n, size := binary.Uvarint(payload)
+		if size <= 0 || uint64(len(payload)-size) < n {
+			return nil, fmt.Errorf("%w: truncated blob header", ErrTypeMismatch)
+		}
+		payload = payload[size:]
+		return Blob{
+			ContentType: string(payload[:n]),
+			Data:        append([]byte(nil), payload[n:]...),
+		}, nil
+	case valueGob:
+		var value interface{}
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		return value, nil
+	case valueGzip:
+		if decompressed {
+			return nil, fmt.Errorf("%w: value compressed twice", ErrTypeMismatch)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		inner, err := io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTypeMismatch, err)
+		}
+		return unmarshalValue(inner, true)
+	default:
+		return nil, fmt.Errorf("%w: unknown value kind %d", ErrTypeMismatch, kind)
+	}
+}
+
+// marshalRaw encodes the values every codec stores without conversion
+func marshalRaw(value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		if !bytes.HasPrefix([]byte(v), []byte(valueMagic)) {
+			return []byte(v), true
+		}
+		return envelope(valueString, []byte(v)), true
+	case []byte:
+		return envelope(valueBytes, v), true
+	case json.RawMessage:
+		return envelope(valueJSON, v), true
+	case Blob:
+		payload := binary.AppendUvarint(nil, uint64(len(v.ContentType)))
+		payload = append(payload, v.ContentType...)
+		return envelope(valueBlob, append(payload, v.Data...)), true
+	}
+	return nil, false
+}
+
+func envelope(kind byte, payload []byte) []byte {
+	data := make([]byte, 0, len(valueMagic)+1+len(payload))
+	data = append(data, valueMagic...)
+	data = append(data, kind)
+	return append(data, payload...)
+}
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// ContextCache is a Cache whose operations honour the deadline and
+// cancellation of a context. Every backend in this package implements it.
+type ContextCache interface {
+	Cache
+	SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	GetCtx(ctx context.Context, key string) (interface{}, error)
+	DeleteCtx(ctx context.Context, key string) error
+	GetAllCtx(ctx context.Context) (map[string]interface{}, error)
+}
+
+// SetContext calls c.SetCtx when c is a ContextCache, and otherwise calls
+// c.Set unless ctx is already done
+func SetContext(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.SetCtx(ctx, key, value, ttl)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(key, value, ttl)
+}
+
+// GetContext calls c.GetCtx when c is a ContextCache, and otherwise calls
+// c.Get unless ctx is already done
+func GetContext(ctx context.Context, c Cache, key string) (interface{}, error) {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.GetCtx(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Get(key)
+}
+
+// DeleteContext calls c.DeleteCtx when c is a ContextCache, and otherwise
+// calls c.Delete unless ctx is already done
+func DeleteContext(ctx context.Context, c Cache, key string) error {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.DeleteCtx(ctx, key)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Delete(key)
+}
+
+// GetAllContext calls c.GetAllCtx when c is a ContextCache, and otherwise
+// calls c.GetAll unless ctx is already done
+func GetAllContext(ctx context.Context, c Cache) (map[string]interface{}, error) {
+	if cc, ok := c.(ContextCache); ok {
+		return cc.GetAllCtx(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetAll()
+}
+
+// withContext runs fn for a client that takes no context and returns early
+// with ctx's error if ctx is done first
+func withContext(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Bind returns a view of c whose plain Cache methods all run with ctx. It lets
+// context-unaware helpers, such as the typed adapters, respect a request's
+// deadline.
+func Bind(ctx context.Context, c Cache) Cache {
+	return &boundCache{ctx: ctx, backend: c}
+}
+
+type boundCache struct {
+	ctx     context.Context
+	backend Cache
+}
+
+func (b *boundCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return SetContext(b.ctx, b.backend, key, value, ttl)
+}
+
+func (b *boundCache) Get(key string) (interface{}, error) {
+	return GetContext(b.ctx, b.backend, key)
+}
+
+func (b *boundCache) Delete(key string) error {
+	return DeleteContext(b.ctx, b.backend, key)
+}
+
+func (b *boundCache) GetAll() (map[string]interface{}, error) {
+	return GetAllContext(b.ctx, b.backend)
+}
+
+// TTLCache is implemented by caches that can report how long an entry has
+// left to live along with its value. A TTL of 0 means the entry never
+// expires.
+type TTLCache interface {
+	GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error)
+}
+
+// GetWithTTL calls c.GetWithTTL when c is a TTLCache. For other caches it
+// gets the value alone and reports a TTL of 0 with ok set to false.
+func GetWithTTL(ctx context.Context, c Cache, key string) (value interface{}, ttl time.Duration, ok bool, err error) {
+	if tc, isTTL := c.(TTLCache); isTTL {
+		value, ttl, err = tc.GetWithTTL(ctx, key)
+		return value, ttl, true, err
+	}
+	value, err = GetContext(ctx, c, key)
+	return value, 0, false, err
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/go-redis/redis/v8"
+)
+
+// Errors shared by every backend. Backend-specific errors are wrapped so that
+// errors.Is matches both the sentinel and the original error.
+var (
+	// ErrNotFound means the key is not in the cache or has expired
+	ErrNotFound = errors.New("cache miss")
+	// ErrBackendUnavailable means the backend could not be reached or did not
+	// answer in time
+	ErrBackendUnavailable = errors.New("cache backend unavailable")
+	// ErrInvalidKey means the backend cannot store the key as given
+	ErrInvalidKey = errors.New("invalid cache key")
+	// ErrValueTooLarge means the value exceeds what the backend can hold
+	ErrValueTooLarge = errors.New("value too large")
+	// ErrTypeMismatch means the value is not of a type the backend or caller
+	// can handle
+	ErrTypeMismatch = errors.New("value type mismatch")
+	// ErrVersionMismatch means a conditional write found the entry changed
+	// or gone since the caller read it
+	ErrVersionMismatch = errors.New("entry version mismatch")
+)
+
+// wrapError attaches kind to a backend error
+func wrapError(kind, err error) error {
+	return fmt.Errorf("%w: %w", kind, err)
+}
+
+// isUnavailable reports whether err means the backend could not be reached
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// redisError maps go-redis errors onto the shared errors
+func redisError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return wrapError(ErrNotFound, err)
+	case errors.Is(err, redis.ErrClosed), isUnavailable(err):
+		return wrapError(ErrBackendUnavailable, err)
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return wrapError(ErrTypeMismatch, err)
+	case strings.HasPrefix(err.Error(), "redis: can't marshal"):
+		return wrapError(ErrTypeMismatch, err)
+	}
+	return err
+}
+
+// memcachedError maps gomemcache errors onto the shared errors
+func memcachedError(err error) error {
+	var timeoutErr *memcache.ConnectTimeoutError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, memcache.ErrCacheMiss):
+		return wrapError(ErrNotFound, err)
+	case errors.Is(err, memcache.ErrMalformedKey):
+		return wrapError(ErrInvalidKey, err)
+	case errors.Is(err, memcache.ErrNoServers), errors.As(err, &timeoutErr), isUnavailable(err):
+		return wrapError(ErrBackendUnavailable, err)
+	case strings.Contains(err.Error(), "too large"):
+		return wrapError(ErrValueTooLarge, err)
+	}
+	return err
+}
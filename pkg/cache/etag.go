@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// AnyETag matches any existing entry in SetIfMatch
+const AnyETag = "*"
+
+// ConditionalCache is implemented by backends that can replace an entry only
+// while it still has a given ETag, atomically with respect to other writers
+type ConditionalCache interface {
+	// SetIfMatch stores value only if key holds a value whose ETag is etag,
+	// or any value when etag is AnyETag. Otherwise, including when key is
+	// not set, it returns ErrVersionMismatch.
+	SetIfMatch(ctx context.Context, key string, value interface{}, ttl time.Duration, etag string) error
+}
+
+// ETag returns a strong entity tag for value: a quoted hash of its JSONCodec
+// encoding. It depends only on the value, so a value has the same ETag in
+// every backend and a write of an equal value keeps it.
+func ETag(value interface{}) (string, error) {
+	data, err := MarshalValue(value)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// matchETag reports whether the current value satisfies etag
+func matchETag(current interface{}, etag string) (bool, error) {
+	if etag == AnyETag {
+		return true, nil
+	}
+	currentETag, err := ETag(current)
+	if err != nil {
+		return false, err
+	}
+	return currentETag == etag, nil
+}
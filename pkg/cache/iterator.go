@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"context"
+	"sort"
+)
+
+const (
+	// DefaultScanCount is the page size hint used when ScanOptions.Count is
+	// not set
+	DefaultScanCount = 100
+	// DefaultScanLimit caps the entries an Iterator yields when
+	// ScanOptions.Limit is not set
+	DefaultScanLimit = 10000
+)
+
+// ScanOptions controls an enumeration of a cache's entries
+type ScanOptions struct {
+	// Match is a glob pattern keys must match. Empty matches every key.
+	Match string
+	// Count hints how many keys each page examines. A page may hold fewer
+	// entries, or none, without the scan being over.
+	Count int
+	// Limit caps the entries an Iterator yields. Zero means DefaultScanLimit;
+	// a negative limit means no cap.
+	Limit int
+}
+
+func (o ScanOptions) count() int {
+	if o.Count <= 0 {
+		return DefaultScanCount
+	}
+	return o.Count
+}
+
+func (o ScanOptions) limit() int {
+	if o.Limit == 0 {
+		return DefaultScanLimit
+	}
+	return o.Limit
+}
+
+// Scanner is implemented by caches that can enumerate their entries a page at
+// a time without holding the whole keyspace in memory. A scan starts at
+// cursor 0 and is over when the returned cursor is 0 again.
+type Scanner interface {
+	Scan(ctx context.Context, cursor uint64, opts ScanOptions) (entries map[string]interface{}, next uint64, err error)
+}
+
+// Entry is a key and value yielded by an Iterator
+type Entry struct {
+	Key   string
+	Value interface{}
+}
+
+// Iterator walks the entries of a Scanner page by page:
+//
+//	it := cache.NewIterator(redisCache, cache.ScanOptions{Match: "user:*"})
+//	for it.Next(ctx) {
+//		fmt.Println(it.Entry().Key)
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Keys written or deleted during the walk may or may not be seen.
+type Iterator struct {
+	scanner Scanner
+	opts    ScanOptions
+
+	cursor    uint64
+	started   bool
+	page      []Entry
+	current   Entry
+	yielded   int
+	truncated bool
+	err       error
+}
+
+// NewIterator returns an Iterator over the entries of s
+func NewIterator(s Scanner, opts ScanOptions) *Iterator {
+	return &Iterator{scanner: s, opts: opts}
+}
+
+// Next advances to the next entry, fetching pages as needed. It returns false
+// when the scan is over, the limit is reached or an error occurred.
+func (it *Iterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.err != nil || (it.started && it.cursor == 0) {
+			return false
+		}
+		entries, next, err := it.scanner.Scan(ctx, it.cursor, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started, it.cursor = true, next
+		it.page = sortedEntries(entries)
+	}
+
+	if limit := it.opts.limit(); limit > 0 && it.yielded >= limit {
+		it.truncated = true
+		return false
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	it.yielded++
+	return true
+}
+
+// Entry returns the entry Next advanced to
+func (it *Iterator) Entry() Entry {
+	return it.current
+}
+
+// Err returns the error that ended the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Truncated reports whether the iteration stopped at the limit before the
+// scan was over
+func (it *Iterator) Truncated() bool {
+	return it.truncated
+}
+
+// Collect gathers the remaining entries of it into a map
+func Collect(ctx context.Context, it *Iterator) (map[string]interface{}, error) {
+	entries := make(map[string]interface{})
+	for it.Next(ctx) {
+		entry := it.Entry()
+		entries[entry.Key] = entry.Value
+	}
+	return entries, it.Err()
+}
+
+func sortedEntries(entries map[string]interface{}) []Entry {
+	page := make([]Entry, 0, len(entries))
+	for key, value := range entries {
+		page = append(page, Entry{Key: key, Value: value})
+	}
+	sort.Slice(page, func(i, j int) bool {
+		return page[i].Key < page[j].Key
+	})
+	return page
+}
@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"crypto/md5"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// MemcachedServer is a memcached server and its share of the keyspace
+// relative to the other servers
+type MemcachedServer struct {
+	Addr   string
+	Weight int
+}
+
+// ParseMemcachedServer parses "host:port" or, as in libketama server lists,
+// "host:port weight". Unix socket paths are accepted in place of host:port.
+func ParseMemcachedServer(s string) (MemcachedServer, error) {
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		return MemcachedServer{Addr: fields[0], Weight: 1}, nil
+	case 2:
+		weight, err := strconv.Atoi(fields[1])
+		if err != nil || weight <= 0 {
+			return MemcachedServer{}, fmt.Errorf("invalid weight in memcached server %q", s)
+		}
+		return MemcachedServer{Addr: fields[0], Weight: weight}, nil
+	default:
+		return MemcachedServer{}, fmt.Errorf("invalid memcached server %q", s)
+	}
+}
+
+// DefaultDeadRetry is how long a failed memcached server is left out of the
+// ring before it is tried again
+const DefaultDeadRetry = 30 * time.Second
+
+// ketamaPointsPerServer is libketama's number of MD5 digests per server at an
+// even weight; each digest gives four points on the ring
+const ketamaPointsPerServer = 40
+
+// ketamaSelector is a memcache.ServerSelector placing keys on a libketama
+// compatible continuum, so other ketama clients given the same server list
+// pick the same server for a key. Servers marked dead are taken out of the
+// ring, moving only their keys to the next servers along it, and put back
+// once the retry delay has passed.
+type ketamaSelector struct {
+	mutex     sync.RWMutex
+	servers   []*ketamaServer
+	ring      []ketamaPoint
+	deadRetry time.Duration
+	revive    time.Time // earliest deadUntil, zero if none are dead
+}
+
+type ketamaServer struct {
+	name      string
+	addr      net.Addr
+	weight    int
+	deadUntil time.Time
+}
+
+type ketamaPoint struct {
+	hash   uint32
+	server *ketamaServer
+}
+
+func newKetamaSelector(servers []MemcachedServer, deadRetry time.Duration) (*ketamaSelector, error) {
+	if len(servers) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	s := &ketamaSelector{deadRetry: deadRetry}
+	for _, server := range servers {
+		addr, err := resolveMemcachedAddr(server.Addr)
+		if err != nil {
+			return nil, err
+		}
+		s.servers = append(s.servers, &ketamaServer{name: server.Addr, addr: addr, weight: max(server.Weight, 1)})
+	}
+	s.rebuild(time.Now())
+	return s, nil
+}
+
+func (s *ketamaSelector) PickServer(key string) (net.Addr, error) {
+	server, err := s.pick(key)
+	if err != nil {
+		return nil, err
+	}
+	return server.addr, nil
+}
+
+// Each calls f for every server currently in the ring
+func (s *ketamaSelector) Each(f func(net.Addr) error) error {
+	s.reviveDue()
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := time.Now()
+	for _, server := range s.servers {
+		if server.deadUntil.After(now) {
+			continue
+		}
+		if err := f(server.addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// owner returns the server key is currently sent to, or nil when none are
+// alive. Callers resolve it before a call so a failure marks the server the
+// call went to, not whichever server owns key once the ring has changed.
+func (s *ketamaSelector) owner(key string) *ketamaServer {
+	server, _ := s.pick(key)
+	return server
+}
+
+// markDead takes server out of the ring for the retry delay. A server that
+// is already dead is left alone, so the other calls that were in flight to it
+// when it failed neither extend its delay nor rebuild the ring again.
+func (s *ketamaSelector) markDead(server *ketamaServer) {
+	if server == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if server.deadUntil.After(now) {
+		return
+	}
+	server.deadUntil = now.Add(s.deadRetry)
+	s.rebuild(now)
+}
+
+// alive returns the addresses of the servers currently in the ring
+func (s *ketamaSelector) alive() []string {
+	var addrs []string
+	s.Each(func(addr net.Addr) error {
+		addrs = append(addrs, addr.String())
+		return nil
+	})
+	return addrs
+}
+
+func (s *ketamaSelector) pick(key string) (*ketamaServer, error) {
+	s.reviveDue()
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.ring) == 0 {
+		return nil, memcache.ErrNoServers
+	}
+	hash := ketamaHash(md5.Sum([]byte(key)), 0)
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= hash
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].server, nil
+}
+
+// reviveDue rebuilds the ring once a dead server's retry delay has passed
+func (s *ketamaSelector) reviveDue() {
+	s.mutex.RLock()
+	revive := s.revive
+	s.mutex.RUnlock()
+	if revive.IsZero() || time.Now().Before(revive) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if now := time.Now(); !s.revive.IsZero() && !now.Before(s.revive) {
+		s.rebuild(now)
+	}
+}
+
+// rebuild lays out the servers on the continuum the way libketama does: each
+// server gets floor(40 * servers * weight/totalWeight) MD5 digests of
+// "name-i", and each digest is split into four points. The shares are always
+// computed over every configured server and dead servers' points are simply
+// left out, so a failure moves only the dead server's keys.
+func (s *ketamaSelector) rebuild(now time.Time) {
+	totalWeight := 0
+	for _, server := range s.servers {
+		totalWeight += server.weight
+	}
+
+	ring := make([]ketamaPoint, 0, len(s.servers)*ketamaPointsPerServer*4)
+	s.revive = time.Time{}
+	for _, server := range s.servers {
+		if server.deadUntil.After(now) {
+			if s.revive.IsZero() || server.deadUntil.Before(s.revive) {
+				s.revive = server.deadUntil
+			}
+			continue
+		}
+		share := float64(server.weight) / float64(totalWeight)
+		digests := int(math.Floor(share * ketamaPointsPerServer * float64(len(s.servers))))
+		for i := 0; i < digests; i++ {
+			digest := md5.Sum([]byte(fmt.Sprintf("%s-%d", server.name, i)))
+			for h := 0; h < 4; h++ {
+				ring = append(ring, ketamaPoint{hash: ketamaHash(digest, h), server: server})
+			}
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	s.ring = ring
+}
+
+// ketamaHash reads the little-endian uint32 at position h of an MD5 digest
+func ketamaHash(digest [md5.Size]byte, h int) uint32 {
+	return uint32(digest[3+h*4])<<24 |
+		uint32(digest[2+h*4])<<16 |
+		uint32(digest[1+h*4])<<8 |
+		uint32(digest[h*4])
+}
+
+func resolveMemcachedAddr(server string) (net.Addr, error) {
+	if strings.Contains(server, "/") {
+		return net.ResolveUnixAddr("unix", server)
+	}
+	return net.ResolveTCPAddr("tcp", server)
+}
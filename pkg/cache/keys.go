@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyLister is implemented by caches that can list and bulk delete their keys
+// by prefix. An empty prefix selects every key.
+type KeyLister interface {
+	Keys(prefix string) ([]string, error)
+	DeletePrefix(prefix string) (int, error)
+}
+
+// Keys returns the live keys starting with prefix, sorted
+func (c *LRUCache) Keys(prefix string) ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0)
+	for key, item := range c.items {
+		if strings.HasPrefix(key, prefix) && item.expiration.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeletePrefix deletes every key starting with prefix and returns how many
+// live entries were removed
+func (c *LRUCache) DeletePrefix(prefix string) (int, error) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := time.Now()
+	deleted := 0
+	for key, item := range c.items {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if item.expiration.After(now) {
+			deleted++
+		}
+		c.removeItem(item, RemovedByDelete)
+	}
+	return deleted, nil
+}
+
+// Keys returns the live keys of every shard starting with prefix, sorted
+func (c *ShardedLRUCache) Keys(prefix string) ([]string, error) {
+	var keys []string
+	for _, shard := range c.shards {
+		shardKeys, _ := shard.Keys(prefix)
+		keys = append(keys, shardKeys...)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeletePrefix deletes every key starting with prefix from all shards
+func (c *ShardedLRUCache) DeletePrefix(prefix string) (int, error) {
+	deleted := 0
+	for _, shard := range c.shards {
+		n, _ := shard.DeletePrefix(prefix)
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// keyIndex records the keys a client wrote and when they expire, for
+// backends that cannot list keys themselves. It only knows about writes made
+// through its own process, and entries the server evicted early stay listed
+// until a read finds them missing.
+type keyIndex struct {
+	mutex    sync.Mutex
+	expiries map[string]time.Time // zero time: no expiry
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{expiries: make(map[string]time.Time)}
+}
+
+func (x *keyIndex) add(key string, ttl time.Duration) {
+	var expiration time.Time
+	if ttl > 0 {
+		expiration = time.Now().Add(ttl)
+	}
+	x.mutex.Lock()
+	x.expiries[key] = expiration
+	x.mutex.Unlock()
+}
+
+func (x *keyIndex) remove(keys ...string) {
+	x.mutex.Lock()
+	for _, key := range keys {
+		delete(x.expiries, key)
+	}
+	x.mutex.Unlock()
+}
+
+// keys returns the unexpired keys starting with prefix, sorted, and forgets
+// the expired ones
+func (x *keyIndex) keys(prefix string) []string {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0)
+	for key, expiration := range x.expiries {
+		if !expiration.IsZero() && !expiration.After(now) {
+			delete(x.expiries, key)
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package cache
+
+import "container/list"
+
+// lfuPolicy evicts the least frequently used key, breaking ties by recency.
+// Keys are grouped in buckets of equal frequency kept in ascending order, so
+// every operation is O(1).
+type lfuPolicy struct {
+	buckets *list.List
+	entries map[string]*lfuEntry
+}
+
+type lfuBucket struct {
+	freq int
+	keys *list.List
+}
+
+type lfuEntry struct {
+	bucket  *list.Element
+	element *list.Element
+}
+
+// NewLFUPolicy returns a least frequently used Policy
+func NewLFUPolicy(capacity int) Policy {
+	return &lfuPolicy{
+		buckets: list.New(),
+		entries: make(map[string]*lfuEntry),
+	}
+}
+
+func (p *lfuPolicy) Add(key string) {
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	p.entries[key] = &lfuEntry{
+		bucket:  front,
+		element: front.Value.(*lfuBucket).keys.PushFront(key),
+	}
+}
+
+func (p *lfuPolicy) Touch(key string) {
+	entry, found := p.entries[key]
+	if !found {
+		return
+	}
+	current := entry.bucket.Value.(*lfuBucket)
+	next := entry.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != current.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: current.freq + 1, keys: list.New()}, entry.bucket)
+	}
+
+	p.unlink(entry)
+	entry.bucket = next
+	entry.element = next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (p *lfuPolicy) Remove(key string) {
+	if entry, found := p.entries[key]; found {
+		p.unlink(entry)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) Evict(key string) {
+	p.Remove(key)
+}
+
+func (p *lfuPolicy) Victim(incoming string) (string, bool) {
+	for bucket := p.buckets.Front(); bucket != nil; bucket = bucket.Next() {
+		if key, ok := backExcept(bucket.Value.(*lfuBucket).keys, incoming); ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func (p *lfuPolicy) unlink(entry *lfuEntry) {
+	bucket := entry.bucket.Value.(*lfuBucket)
+	bucket.keys.Remove(entry.element)
+	if bucket.keys.Len() == 0 {
+		p.buckets.Remove(entry.bucket)
+	}
+}
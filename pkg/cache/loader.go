@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoaderFunc computes the value of a key that is missing from the cache
+type LoaderFunc func(key string) (interface{}, error)
+
+// LoadingCache is a Cache that can fill its own misses. Concurrent GetOrLoad
+// calls for the same key share a single call to the loader.
+type LoadingCache interface {
+	Cache
+	GetOrLoad(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error)
+}
+
+// LoadGroup deduplicates concurrent work for the same key within the process:
+// while a call for a key is in flight, later callers wait for and share its
+// result. The zero value is ready to use.
+type LoadGroup struct {
+	mutex sync.Mutex
+	calls map[string]*loadCall
+}
+
+type loadCall struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// Do runs fn for key unless a call for key is already in flight, in which case
+// it waits for that call and returns its result. If fn panics, the waiters get
+// an error and the panic continues in the caller that ran fn.
+func (g *LoadGroup) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*loadCall)
+	}
+	if call, found := g.calls[key]; found {
+		g.mutex.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			call.value, call.err = nil, fmt.Errorf("load of %q panicked: %v", key, recovered)
+		}
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+// getOrLoad implements GetOrLoad on top of any Cache. The loaded value is
+// returned even if storing it fails, together with the error.
+func getOrLoad(c Cache, group *LoadGroup, key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	if value, err := c.Get(key); err == nil {
+		return value, nil
+	}
+	return group.Do(key, func() (interface{}, error) {
+		// A previous flight may have filled the key while we waited to start
+		if value, err := c.Get(key); err == nil {
+			return value, nil
+		}
+		return loadAndStore(c, key, loader, ttl)
+	})
+}
+
+func loadAndStore(c Cache, key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	value, err := loader(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Set(key, value, ttl); err != nil {
+		return value, fmt.Errorf("failed to store loaded value: %w", err)
+	}
+	return value, nil
+}
@@ -1,8 +1,9 @@
 package cache
 
 import (
-	"container/list"
-	"errors"
+	"container/heap"
+	"context"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -11,74 +12,321 @@ type CacheItem struct {
 	key        string
 	value      interface{}
 	expiration time.Time
+	size       int64
+	index      int
+	lastAccess time.Time
+
+	// Soft expiry: past refreshAt the value is stale and is reloaded in the
+	// background while still being served until expiration
+	ttl          time.Duration
+	refreshAfter time.Duration
+	refreshAt    time.Time
+	refreshing   bool
 }
 
+// LRUCache is a thread-safe in-memory cache with TTLs. It evicts the least
+// recently used entry by default; WithPolicy selects another eviction policy.
 type LRUCache struct {
 	capacity int
-	items    map[string]*list.Element
-	list     *list.List
+	items    map[string]*CacheItem
+	policy   Policy
 	mutex    sync.Mutex
+
+	// expiries orders entries by expiration once tracking is set: with the
+	// janitor, or after entries were written with different TTLs. Until then
+	// every entry shares commonTTL, expirations follow write order and the
+	// policy's victim is checked for expiry instead, which keeps writes
+	// from paying for the heap.
+	expiries  expiryHeap
+	tracking  bool
+	commonTTL time.Duration
+	sawTTL    bool
+
+	policyFactory PolicyFactory
+
+	maxBytes int64
+	bytes    int64
+	sizer    Sizer
+	// maxValueBytes is the largest value accepted when it differs from
+	// maxBytes, as for the shards of a ShardedLRUCache
+	maxValueBytes int64
+
+	onEvict  []RemovalFunc
+	onExpire []RemovalFunc
+	onDelete []RemovalFunc
+	removed  []removal
+
+	loads        LoadGroup
+	loader       LoaderFunc
+	refreshRatio float64
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+	snapshotCodec    SnapshotCodec
+
+	sweepInterval time.Duration
+	stop          chan struct{}
+	closeOnce     sync.Once
+}
+
+// LRUOption configures optional behaviour of an LRUCache
+type LRUOption func(*LRUCache)
+
+// WithSweepInterval enables active expiration: a background janitor removes
+// expired entries every interval instead of waiting for a Get to find them.
+// Call Close to stop the janitor.
+func WithSweepInterval(interval time.Duration) LRUOption {
+	return func(c *LRUCache) {
+		c.sweepInterval = interval
+	}
+}
+
+// WithMaxBytes bounds the cache by the total estimated size of its entries.
+// Least recently used entries are evicted until a new value fits. It can be
+// combined with an entry capacity; pass a capacity <= 0 to rely on bytes alone.
+func WithMaxBytes(maxBytes int64) LRUOption {
+	return func(c *LRUCache) {
+		c.maxBytes = maxBytes
+	}
 }
 
-func NewLRUCache(capacity int) *LRUCache {
-	return &LRUCache{
+// WithSizer replaces DefaultSizer for estimating the size of entries
+func WithSizer(sizer Sizer) LRUOption {
+	return func(c *LRUCache) {
+		c.sizer = sizer
+	}
+}
+
+// WithLoader registers the loader used to refresh stale entries in the
+// background. Without it soft TTLs have no effect.
+func WithLoader(loader LoaderFunc) LRUOption {
+	return func(c *LRUCache) {
+		c.loader = loader
+	}
+}
+
+// WithRefreshAhead gives every entry written by Set a soft TTL of ratio * ttl
+// (for example 0.8), so hot keys are reloaded by the WithLoader loader before
+// they hard-expire
+func WithRefreshAhead(ratio float64) LRUOption {
+	return func(c *LRUCache) {
+		c.refreshRatio = ratio
+	}
+}
+
+// NewLRUCache creates an LRUCache holding at most capacity entries. A
+// capacity <= 0 disables the entry limit.
+func NewLRUCache(capacity int, opts ...LRUOption) *LRUCache {
+	c := &LRUCache{
 		capacity: capacity,
-		items:    make(map[string]*list.Element),
-		list:     list.New(),
+		items:    make(map[string]*CacheItem),
+		sizer:    DefaultSizer,
+		stop:     make(chan struct{}),
+
+		policyFactory: NewLRUPolicy,
+		snapshotCodec: GobSnapshotCodec{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.policy = c.policyFactory(capacity)
+	if c.sweepInterval > 0 {
+		c.tracking = true
+		go c.janitor()
+	}
+	if c.snapshotPath != "" {
+		startSnapshots(c.snapshotPath, c.snapshotInterval, c.stop, c.LoadSnapshotFile, c.SaveSnapshotFile)
 	}
+	return c
 }
 
+// Set sets a value in the cache. A ttl <= 0 stores it without an expiry, as
+// Redis and Memcached do; it then leaves only when evicted or deleted.
 func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return c.SetWithRefresh(key, value, c.refreshAfter(ttl), ttl)
+}
+
+// SetWithRefresh sets a value with a soft and a hard TTL. A Get after
+// refreshAfter returns the stale value immediately and triggers a background
+// reload through the WithLoader loader; only after ttl is the entry a miss.
+// A refreshAfter <= 0 disables the soft TTL.
+func (c *LRUCache) SetWithRefresh(key string, value interface{}, refreshAfter, ttl time.Duration) error {
+	size, err := c.sizeOf(key, value)
+	if err != nil {
+		return err
+	}
+
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
+	c.store(key, value, size, refreshAfter, ttl)
+	return nil
+}
 
-	if element, found := c.items[key]; found {
-		c.list.MoveToFront(element)
-		element.Value.(*CacheItem).value = value
-		element.Value.(*CacheItem).expiration = time.Now().Add(ttl)
-		return nil
+// SetIfMatch sets a value only while the entry's current value has the given
+// ETag, or exists at all for AnyETag
+func (c *LRUCache) SetIfMatch(ctx context.Context, key string, value interface{}, ttl time.Duration, etag string) error {
+	_, err := c.setIf(ctx, key, value, ttl, func(item *CacheItem) (bool, error) {
+		if item == nil {
+			return false, nil
+		}
+		return matchETag(item.value, etag)
+	})
+	return err
+}
+
+// SetNX sets a value only if key is not set
+func (c *LRUCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	stored, err := c.setIf(ctx, key, value, ttl, func(item *CacheItem) (bool, error) {
+		return item == nil, nil
+	})
+	return stored, ignoreMismatch(err)
+}
+
+// SetXX sets a value only if key is set
+func (c *LRUCache) SetXX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	stored, err := c.setIf(ctx, key, value, ttl, func(item *CacheItem) (bool, error) {
+		return item != nil, nil
+	})
+	return stored, ignoreMismatch(err)
+}
+
+// CompareAndSwap sets new only if key holds a value equal to old
+func (c *LRUCache) CompareAndSwap(ctx context.Context, key string, old, new interface{}, ttl time.Duration) (bool, error) {
+	return compareAndSwap(ctx, c, key, old, new, ttl)
+}
+
+// setIf stores value if ok accepts the live entry for key, or nil when there
+// is none, all under the mutex. It returns ErrVersionMismatch when ok refuses.
+func (c *LRUCache) setIf(ctx context.Context, key string, value interface{}, ttl time.Duration, ok func(*CacheItem) (bool, error)) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	size, err := c.sizeOf(key, value)
+	if err != nil {
+		return false, err
 	}
 
-	if c.list.Len() >= c.capacity {
-		c.evict()
+	c.mutex.Lock()
+	defer c.unlock()
+	item, found := c.items[key]
+	if found && !item.expiration.After(time.Now()) {
+		item = nil
+	}
+	if accepted, err := ok(item); err != nil {
+		return false, err
+	} else if !accepted {
+		return false, ErrVersionMismatch
+	}
+	c.store(key, value, size, c.refreshAfter(ttl), ttl)
+	return true, nil
+}
+
+// refreshAfter is the soft TTL of a write with the given hard TTL
+func (c *LRUCache) refreshAfter(ttl time.Duration) time.Duration {
+	if c.refreshRatio <= 0 {
+		return 0
+	}
+	return time.Duration(float64(ttl) * c.refreshRatio)
+}
+
+// sizeOf estimates the size of an entry when a byte budget is set
+func (c *LRUCache) sizeOf(key string, value interface{}) (int64, error) {
+	if c.maxBytes <= 0 {
+		return 0, nil
+	}
+	size := c.sizer(key, value)
+	limit := c.maxBytes
+	if c.maxValueBytes > 0 {
+		limit = c.maxValueBytes
+	}
+	if size > limit {
+		return 0, fmt.Errorf("%w: %d bytes exceeds the cache byte budget", ErrValueTooLarge, size)
+	}
+	return size, nil
+}
+
+// store adds or replaces an entry. The caller holds the mutex.
+func (c *LRUCache) store(key string, value interface{}, size int64, refreshAfter, ttl time.Duration) {
+	now := time.Now()
+	c.noteTTL(ttl)
+	if item, found := c.items[key]; found {
+		c.policy.Touch(key)
+		item.value = value
+		item.expiration = expiresAt(now, ttl)
+		item.lastAccess = now
+		item.setRefresh(now, refreshAfter, ttl)
+		if c.tracking {
+			heap.Fix(&c.expiries, item.index)
+		}
+		c.bytes += size - item.size
+		item.size = size
+		for c.bytes > c.maxBytes && c.evict(key, now) {
+		}
+		return
+	}
+
+	for c.overBudget(size) && c.evict(key, now) {
 	}
 
 	item := &CacheItem{
 		key:        key,
 		value:      value,
-		expiration: time.Now().Add(ttl),
+		expiration: expiresAt(now, ttl),
+		size:       size,
+		lastAccess: now,
 	}
-	element := c.list.PushFront(item)
-	c.items[key] = element
-	return nil
+	item.setRefresh(now, refreshAfter, ttl)
+	c.items[key] = item
+	c.policy.Add(key)
+	if c.tracking {
+		heap.Push(&c.expiries, item)
+	}
+	c.bytes += size
 }
 
 func (c *LRUCache) Get(key string) (interface{}, error) {
+	value, _, err := c.getWithTTL(key)
+	return value, err
+}
+
+// GetWithTTL gets a value along with the time it has left to live
+func (c *LRUCache) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	return c.getWithTTL(key)
+}
+
+func (c *LRUCache) getWithTTL(key string) (interface{}, time.Duration, error) {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	if element, found := c.items[key]; found {
-		if element.Value.(*CacheItem).expiration.After(time.Now()) {
-			c.list.MoveToFront(element)
-			return element.Value.(*CacheItem).value, nil
+	if item, found := c.items[key]; found {
+		now := time.Now()
+		if item.expiration.After(now) {
+			c.policy.Touch(key)
+			item.lastAccess = now
+			if c.loader != nil && !item.refreshAt.IsZero() && !now.Before(item.refreshAt) && !item.refreshing {
+				item.refreshing = true
+				go c.refresh(item, item.refreshAfter, item.ttl)
+			}
+			return item.value, item.remaining(now), nil
 		}
-		c.list.Remove(element)
-		delete(c.items, key)
-		return nil, errors.New("cache miss")
+		c.removeItem(item, RemovedByExpiry)
+		return nil, 0, ErrNotFound
 	}
-	return nil, errors.New("cache miss")
+	return nil, 0, ErrNotFound
 }
 
 func (c *LRUCache) Delete(key string) error {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	defer c.unlock()
 
-	if element, found := c.items[key]; found {
-		c.list.Remove(element)
-		delete(c.items, key)
+	if item, found := c.items[key]; found {
+		c.removeItem(item, RemovedByDelete)
 		return nil
 	}
-	return errors.New("cache miss")
+	return ErrNotFound
 }
 
 func (c *LRUCache) GetAll() (map[string]interface{}, error) {
@@ -86,17 +334,311 @@ func (c *LRUCache) GetAll() (map[string]interface{}, error) {
 	defer c.mutex.Unlock()
 
 	allItems := make(map[string]interface{})
-	for key, element := range c.items {
-		if element.Value.(*CacheItem).expiration.After(time.Now()) {
-			allItems[key] = element.Value.(*CacheItem).value
+	for key, item := range c.items {
+		if item.expiration.After(time.Now()) {
+			allItems[key] = item.value
 		}
 	}
 	return allItems, nil
 }
 
-func (c *LRUCache) evict() {
-	if element := c.list.Back(); element != nil {
-		c.list.Remove(element)
-		delete(c.items, element.Value.(*CacheItem).key)
+// SetCtx is Set for callers holding a context. The in-memory cache never
+// blocks, so ctx is only checked before starting.
+func (c *LRUCache) SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Set(key, value, ttl)
+}
+
+// GetCtx is Get for callers holding a context
+func (c *LRUCache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Get(key)
+}
+
+// DeleteCtx is Delete for callers holding a context
+func (c *LRUCache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Delete(key)
+}
+
+// GetAllCtx is GetAll for callers holding a context
+func (c *LRUCache) GetAllCtx(ctx context.Context) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetAll()
+}
+
+// GetOrLoad returns the cached value for key, calling loader and storing its
+// result on a miss. Concurrent misses for the same key share one loader call.
+func (c *LRUCache) GetOrLoad(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	return getOrLoad(c, &c.loads, key, loader, ttl)
+}
+
+// Len returns the number of stored entries, including expired ones that have
+// not been reclaimed yet
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.items)
+}
+
+// Bytes returns the estimated size of all stored entries. It is only tracked
+// when the cache was created WithMaxBytes.
+func (c *LRUCache) Bytes() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.bytes
+}
+
+// OnEvict registers fn to be called for entries evicted to make room
+func (c *LRUCache) OnEvict(fn RemovalFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onEvict = append(c.onEvict, fn)
+}
+
+// OnExpire registers fn to be called for entries removed because their TTL
+// ran out, whether found by a Get, the janitor or eviction
+func (c *LRUCache) OnExpire(fn RemovalFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onExpire = append(c.onExpire, fn)
+}
+
+// OnDelete registers fn to be called for entries removed by Delete
+func (c *LRUCache) OnDelete(fn RemovalFunc) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onDelete = append(c.onDelete, fn)
+}
+
+// DeleteExpired removes every expired entry and returns how many were removed
+func (c *LRUCache) DeleteExpired() int {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := time.Now()
+	removed := 0
+	if !c.tracking {
+		for _, item := range c.items {
+			if !item.expiration.After(now) {
+				c.removeItem(item, RemovedByExpiry)
+				removed++
+			}
+		}
+		return removed
+	}
+	for len(c.expiries) > 0 && !c.expiries[0].expiration.After(now) {
+		c.removeItem(c.expiries[0], RemovedByExpiry)
+		removed++
 	}
+	return removed
+}
+
+// noteTTL starts tracking expirations in the heap once an entry is written
+// with a TTL other than the one every entry so far shared. The caller holds
+// the mutex.
+func (c *LRUCache) noteTTL(ttl time.Duration) {
+	switch {
+	case c.tracking:
+	case !c.sawTTL:
+		c.sawTTL, c.commonTTL = true, ttl
+	case ttl != c.commonTTL:
+		c.tracking = true
+		c.expiries = make(expiryHeap, 0, len(c.items))
+		for _, item := range c.items {
+			item.index = len(c.expiries)
+			c.expiries = append(c.expiries, item)
+		}
+		heap.Init(&c.expiries)
+	}
+}
+
+// Close stops the background janitor, if any, and writes the final snapshot
+// when WithSnapshotFile is used. The cache remains usable.
+func (c *LRUCache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		if c.snapshotPath != "" {
+			err = c.SaveSnapshotFile(c.snapshotPath)
+		}
+	})
+	return err
+}
+
+// refresh reloads a stale entry. On failure the stale value keeps being served
+// until it hard-expires, and the next read retries the reload. The reload is
+// dropped if the entry was deleted or written while the loader ran, since it
+// would bring back a deleted key or overwrite a newer value.
+func (c *LRUCache) refresh(item *CacheItem, refreshAfter, ttl time.Duration) {
+	value, err := c.loader(item.key)
+	var size int64
+	if err == nil {
+		size, err = c.sizeOf(item.key, value)
+	}
+
+	c.mutex.Lock()
+	defer c.unlock()
+	if c.items[item.key] != item || !item.refreshing {
+		return
+	}
+	if err != nil {
+		item.refreshing = false
+		return
+	}
+	c.store(item.key, value, size, refreshAfter, ttl)
+}
+
+func (c *LRUCache) janitor() {
+	ticker := time.NewTicker(c.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// overBudget reports whether adding an entry of the given size would exceed
+// the entry capacity or the byte budget
+func (c *LRUCache) overBudget(size int64) bool {
+	if c.capacity > 0 && len(c.items) >= c.capacity {
+		return true
+	}
+	return c.maxBytes > 0 && c.bytes+size > c.maxBytes
+}
+
+// evict removes one entry to make room for key and reports whether it found
+// one. An already expired entry is reclaimed in preference to the policy's
+// victim, and key itself is never chosen. Without the expiry heap only the
+// victim is checked for expiry.
+func (c *LRUCache) evict(key string, now time.Time) bool {
+	if c.tracking && len(c.expiries) > 0 && !c.expiries[0].expiration.After(now) && c.expiries[0].key != key {
+		c.removeItem(c.expiries[0], RemovedByExpiry)
+		return true
+	}
+
+	victim, ok := c.policy.Victim(key)
+	if !ok || victim == key {
+		return false
+	}
+	item := c.items[victim]
+	if !item.expiration.After(now) {
+		c.removeItem(item, RemovedByExpiry)
+		return true
+	}
+	c.detach(item, RemovedByEviction)
+	c.policy.Evict(victim)
+	return true
+}
+
+// removeItem drops an entry that was deleted or expired
+func (c *LRUCache) removeItem(item *CacheItem, reason RemovalReason) {
+	c.detach(item, reason)
+	c.policy.Remove(item.key)
+}
+
+func (c *LRUCache) detach(item *CacheItem, reason RemovalReason) {
+	delete(c.items, item.key)
+	if c.tracking {
+		heap.Remove(&c.expiries, item.index)
+	}
+	c.bytes -= item.size
+	if callbacks := c.callbacks(reason); len(callbacks) > 0 {
+		c.removed = append(c.removed, removal{key: item.key, value: item.value, reason: reason, callbacks: callbacks})
+	}
+}
+
+func (c *LRUCache) callbacks(reason RemovalReason) []RemovalFunc {
+	switch reason {
+	case RemovedByEviction:
+		return c.onEvict
+	case RemovedByExpiry:
+		return c.onExpire
+	default:
+		return c.onDelete
+	}
+}
+
+// unlock releases the mutex and then runs the callbacks for entries removed
+// while it was held
+func (c *LRUCache) unlock() {
+	removed := c.removed
+	c.removed = nil
+	c.mutex.Unlock()
+
+	for _, r := range removed {
+		for _, fn := range r.callbacks {
+			fn(r.key, r.value, r.reason)
+		}
+	}
+}
+
+func (item *CacheItem) setRefresh(now time.Time, refreshAfter, ttl time.Duration) {
+	item.ttl = ttl
+	item.refreshAfter = refreshAfter
+	item.refreshing = false
+	item.refreshAt = time.Time{}
+	if refreshAfter > 0 && refreshAfter < ttl {
+		item.refreshAt = now.Add(refreshAfter)
+	}
+}
+
+// neverExpires is the expiration of entries stored without a TTL. It sorts
+// after every real expiration, so such entries sit at the bottom of the
+// expiry heap.
+var neverExpires = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return neverExpires
+	}
+	return now.Add(ttl)
+}
+
+// remaining returns the time item has left to live, or 0 if it never expires
+func (item *CacheItem) remaining(now time.Time) time.Duration {
+	if item.expiration.Equal(neverExpires) {
+		return 0
+	}
+	return item.expiration.Sub(now)
+}
+
+// expiryHeap orders items by expiration, soonest first
+type expiryHeap []*CacheItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiration.Before(h[j].expiration) }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	item := x.(*CacheItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
 }
@@ -1,44 +1,419 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
 type MemcachedCache struct {
-	client *memcache.Client
+	client    *memcache.Client
+	selector  *ketamaSelector
+	deadRetry time.Duration
+	codec     Codec
+	index     *keyIndex
+	loads     LoadGroup
 }
 
-func NewMemcachedCache(address string) (*MemcachedCache, error) {
-	client := memcache.New(address)
-	if err := client.Ping(); err != nil {
-		return nil, err
+// MemcachedOption configures a MemcachedCache
+type MemcachedOption func(*MemcachedCache)
+
+// WithKeyIndex makes the cache remember the keys it writes, since memcached
+// cannot list its keys. GetAll, Scan, Keys and DeletePrefix then cover every
+// entry written through this cache; without the index they see nothing.
+func WithKeyIndex() MemcachedOption {
+	return func(c *MemcachedCache) {
+		c.index = newKeyIndex()
+	}
+}
+
+// WithDeadRetry sets how long a server that could not be reached is left out
+// of the ring before it is tried again. The default is DefaultDeadRetry.
+func WithDeadRetry(d time.Duration) MemcachedOption {
+	return func(c *MemcachedCache) {
+		c.deadRetry = d
+	}
+}
+
+// WithCodec replaces JSONCodec as the encoding of stored values. Values
+// already stored stay readable.
+func WithCodec(codec Codec) MemcachedOption {
+	return func(c *MemcachedCache) {
+		c.codec = codec
 	}
-	return &MemcachedCache{client: client}, nil
+}
+
+// memcachedMaxItemSize is memcached's default item size limit (-I 1m)
+const memcachedMaxItemSize = 1 << 20
+
+// memcachedMaxRelativeTTL is the longest expiration memcached reads as
+// relative; larger values are taken as Unix timestamps
+const memcachedMaxRelativeTTL = 30 * 24 * time.Hour
+
+// memcachedExpiration converts a TTL into memcached's expiration field: 0 for
+// no expiry, whole seconds rounded up, or an absolute time past 30 days
+func memcachedExpiration(ttl time.Duration, now time.Time) int32 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl > memcachedMaxRelativeTTL:
+		return int32(now.Add(ttl).Unix())
+	default:
+		return int32((ttl + time.Second - 1) / time.Second)
+	}
+}
+
+// memcachedBatchSize bounds the keys fetched by one GetMulti
+const memcachedBatchSize = 100
+
+func NewMemcachedCache(address string, opts ...MemcachedOption) (*MemcachedCache, error) {
+	return NewMemcachedCluster([]MemcachedServer{{Addr: address, Weight: 1}}, opts...)
+}
+
+// NewMemcachedCluster creates a MemcachedCache spreading keys over servers by
+// ketama consistent hashing. Servers that do not answer at startup begin
+// marked dead; it fails only when none of them answer.
+func NewMemcachedCluster(servers []MemcachedServer, opts ...MemcachedOption) (*MemcachedCache, error) {
+	c := &MemcachedCache{deadRetry: DefaultDeadRetry, codec: JSONCodec{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	selector, err := newKetamaSelector(servers, c.deadRetry)
+	if err != nil {
+		return nil, memcachedError(err)
+	}
+	var pingErr error
+	for _, server := range selector.servers {
+		if err := memcache.New(server.name).Ping(); err != nil {
+			pingErr = fmt.Errorf("%s: %w", server.name, err)
+			selector.markDead(server)
+		}
+	}
+	if len(selector.alive()) == 0 {
+		return nil, memcachedError(pingErr)
+	}
+
+	c.selector = selector
+	c.client = memcache.NewFromSelector(selector)
+	return c, nil
+}
+
+// Ping checks that every server in the ring answers
+func (c *MemcachedCache) Ping(ctx context.Context) error {
+	_, err := withContext(ctx, func() (interface{}, error) {
+		return nil, memcachedError(c.client.Ping())
+	})
+	return err
+}
+
+// LiveServers returns the addresses of the servers currently receiving keys
+func (c *MemcachedCache) LiveServers() []string {
+	return c.selector.alive()
+}
+
+// SetTimeout sets the socket read/write timeout of the client. Call it before
+// the cache is shared between goroutines.
+func (c *MemcachedCache) SetTimeout(timeout time.Duration) {
+	c.client.Timeout = timeout
 }
 
 func (c *MemcachedCache) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := c.encode(key, value)
+	if err != nil {
+		return err
+	}
 	item := &memcache.Item{
 		Key:        key,
-		Value:      []byte(value.(string)),
-		Expiration: int32(ttl.Seconds()),
+		Value:      data,
+		Expiration: memcachedExpiration(ttl, time.Now()),
+	}
+	server := c.selector.owner(key)
+	if err := c.client.Set(item); err != nil {
+		return c.failed(server, err)
 	}
-	return c.client.Set(item)
+	if c.index != nil {
+		c.index.add(key, ttl)
+	}
+	return nil
 }
 
-func (c *MemcachedCache) Get(key string) (interface{}, error) {
+// SetIfMatch sets a value only while the stored value has the given ETag. The
+// write uses the CAS token of the read, so a concurrent write makes it fail
+// with ErrVersionMismatch.
+func (c *MemcachedCache) SetIfMatch(ctx context.Context, key string, value interface{}, ttl time.Duration, etag string) error {
+	_, err := withContext(ctx, func() (interface{}, error) {
+		return nil, c.setIfMatch(key, value, ttl, etag)
+	})
+	return err
+}
+
+func (c *MemcachedCache) setIfMatch(key string, value interface{}, ttl time.Duration, etag string) error {
+	data, err := c.encode(key, value)
+	if err != nil {
+		return err
+	}
+	server := c.selector.owner(key)
 	item, err := c.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return ErrVersionMismatch
+	} else if err != nil {
+		return c.failed(server, err)
+	}
+	current, err := c.codec.Unmarshal(item.Value)
+	if err != nil {
+		return err
+	}
+	if ok, err := matchETag(current, etag); err != nil {
+		return err
+	} else if !ok {
+		return ErrVersionMismatch
+	}
+
+	item.Value = data
+	item.Expiration = memcachedExpiration(ttl, time.Now())
+	err = c.client.CompareAndSwap(item)
+	if err == memcache.ErrCASConflict || err == memcache.ErrNotStored || err == memcache.ErrCacheMiss {
+		return ErrVersionMismatch
+	} else if err != nil {
+		return c.failed(server, err)
+	}
+	if c.index != nil {
+		c.index.add(key, ttl)
+	}
+	return nil
+}
+
+// SetNX sets a value only if key is not set, with memcached's add
+func (c *MemcachedCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.storeIf(ctx, key, value, ttl, c.client.Add)
+}
+
+// SetXX sets a value only if key is set, with memcached's replace
+func (c *MemcachedCache) SetXX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.storeIf(ctx, key, value, ttl, c.client.Replace)
+}
+
+// CompareAndSwap sets new only if key holds a value equal to old, using the
+// CAS token of the read
+func (c *MemcachedCache) CompareAndSwap(ctx context.Context, key string, old, new interface{}, ttl time.Duration) (bool, error) {
+	return compareAndSwap(ctx, c, key, old, new, ttl)
+}
+
+// storeIf writes with a memcached command that refuses with ErrNotStored
+func (c *MemcachedCache) storeIf(ctx context.Context, key string, value interface{}, ttl time.Duration, store func(*memcache.Item) error) (bool, error) {
+	stored, err := withContext(ctx, func() (interface{}, error) {
+		data, err := c.encode(key, value)
+		if err != nil {
+			return false, err
+		}
+		server := c.selector.owner(key)
+		err = store(&memcache.Item{
+			Key:        key,
+			Value:      data,
+			Expiration: memcachedExpiration(ttl, time.Now()),
+		})
+		if err == memcache.ErrNotStored {
+			return false, nil
+		} else if err != nil {
+			return false, c.failed(server, err)
+		}
+		if c.index != nil {
+			c.index.add(key, ttl)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return stored.(bool), nil
+}
+
+// encode encodes value with the cache's codec, checking it fits in an item
+func (c *MemcachedCache) encode(key string, value interface{}) ([]byte, error) {
+	data, err := c.codec.Marshal(value)
 	if err != nil {
 		return nil, err
 	}
-	return string(item.Value), nil
+	if len(key)+len(data) > memcachedMaxItemSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the memcached item size limit", ErrValueTooLarge, len(data))
+	}
+	return data, nil
+}
+
+func (c *MemcachedCache) Get(key string) (interface{}, error) {
+	server := c.selector.owner(key)
+	item, err := c.client.Get(key)
+	if err != nil {
+		if err == memcache.ErrCacheMiss && c.index != nil {
+			c.index.remove(key)
+		}
+		return nil, c.failed(server, err)
+	}
+	return c.codec.Unmarshal(item.Value)
+}
+
+// GetOrLoad returns the cached value for key, calling loader and storing its
+// result on a miss. Concurrent misses for the same key in this process share
+// one loader call.
+func (c *MemcachedCache) GetOrLoad(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	return getOrLoad(c, &c.loads, key, loader, ttl)
 }
 
 func (c *MemcachedCache) Delete(key string) error {
-	return c.client.Delete(key)
+	if c.index != nil {
+		c.index.remove(key)
+	}
+	server := c.selector.owner(key)
+	return c.failed(server, c.client.Delete(key))
 }
 
+// GetAll returns the entries recorded in the key index, or nothing when the
+// cache was created without WithKeyIndex
 func (c *MemcachedCache) GetAll() (map[string]interface{}, error) {
-	return map[string]interface{}{}, nil
+	if c.index == nil {
+		return map[string]interface{}{}, nil
+	}
+	return c.getMulti(c.index.keys(""))
+}
+
+// Scan returns one page of the indexed entries whose keys match opts.Match.
+// The cursor is an offset into the sorted key list, so keys written during a
+// scan may shift pages.
+func (c *MemcachedCache) Scan(ctx context.Context, cursor uint64, opts ScanOptions) (map[string]interface{}, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if c.index == nil {
+		return map[string]interface{}{}, 0, nil
+	}
+
+	keys := c.index.keys("")
+	if opts.Match != "" {
+		matched := keys[:0]
+		for _, key := range keys {
+			if ok, err := path.Match(opts.Match, key); err != nil {
+				return nil, 0, err
+			} else if ok {
+				matched = append(matched, key)
+			}
+		}
+		keys = matched
+	}
+	if cursor >= uint64(len(keys)) {
+		return map[string]interface{}{}, 0, nil
+	}
+
+	end := min(cursor+uint64(opts.count()), uint64(len(keys)))
+	entries, err := c.getMulti(keys[cursor:end])
+	if err != nil {
+		return nil, 0, err
+	}
+	if end == uint64(len(keys)) {
+		end = 0
+	}
+	return entries, end, nil
+}
+
+// Keys returns the indexed keys starting with prefix, sorted
+func (c *MemcachedCache) Keys(prefix string) ([]string, error) {
+	if c.index == nil {
+		return []string{}, nil
+	}
+	return c.index.keys(prefix), nil
+}
+
+// DeletePrefix deletes every indexed key starting with prefix and returns how
+// many were still stored
+func (c *MemcachedCache) DeletePrefix(prefix string) (int, error) {
+	if c.index == nil {
+		return 0, nil
+	}
+
+	deleted := 0
+	for _, key := range c.index.keys(prefix) {
+		server := c.selector.owner(key)
+		err := c.client.Delete(key)
+		if err != nil && err != memcache.ErrCacheMiss {
+			return deleted, c.failed(server, err)
+		}
+		c.index.remove(key)
+		if err == nil {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// getMulti fetches keys in batches and drops the ones memcached no longer
+// holds from the index
+func (c *MemcachedCache) getMulti(keys []string) (map[string]interface{}, error) {
+	entries := make(map[string]interface{}, len(keys))
+	for start := 0; start < len(keys); start += memcachedBatchSize {
+		batch := keys[start:min(start+memcachedBatchSize, len(keys))]
+		items, err := c.client.GetMulti(batch)
+		if err != nil {
+			return nil, memcachedError(err)
+		}
+		for _, key := range batch {
+			if item, found := items[key]; found {
+				if entries[key], err = c.codec.Unmarshal(item.Value); err != nil {
+					return nil, err
+				}
+			} else {
+				c.index.remove(key)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// The memcached client takes no context, so the Ctx variants run the call in
+// the background and stop waiting for it once ctx is done. The client's own
+// timeout still bounds the abandoned call.
+
+// SetCtx sets a value in the cache, giving up when ctx is done
+func (c *MemcachedCache) SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	_, err := withContext(ctx, func() (interface{}, error) {
+		return nil, c.Set(key, value, ttl)
+	})
+	return err
+}
+
+// GetCtx gets a value from the cache, giving up when ctx is done
+func (c *MemcachedCache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	return withContext(ctx, func() (interface{}, error) {
+		return c.Get(key)
+	})
+}
+
+// DeleteCtx deletes a value from the cache, giving up when ctx is done
+func (c *MemcachedCache) DeleteCtx(ctx context.Context, key string) error {
+	_, err := withContext(ctx, func() (interface{}, error) {
+		return nil, c.Delete(key)
+	})
+	return err
+}
+
+// GetAllCtx is GetAll for callers holding a context
+func (c *MemcachedCache) GetAllCtx(ctx context.Context) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetAll()
+}
+
+// failed maps err onto the shared errors and, when server, which the call
+// was sent to, could not be reached, takes it out of the ring so the following
+// calls rehash its keys onto the remaining servers
+func (c *MemcachedCache) failed(server *ketamaServer, err error) error {
+	err = memcachedError(err)
+	if errors.Is(err, ErrBackendUnavailable) && !errors.Is(err, memcache.ErrNoServers) {
+		c.selector.markDead(server)
+	}
+	return err
 }
@@ -0,0 +1,128 @@
+package cache
+
+import "container/list"
+
+// Policy decides which resident key an in-memory cache gives up when it needs
+// room. The cache serializes all calls, so implementations need no locking.
+type Policy interface {
+	// Add records that key became resident
+	Add(key string)
+	// Touch records a hit on a resident key
+	Touch(key string)
+	// Remove forgets a key that was deleted or expired
+	Remove(key string)
+	// Evict forgets a key the cache dropped on the policy's advice. Policies
+	// that keep history of evicted keys record it here.
+	Evict(key string)
+	// Victim names the resident key to drop so that incoming can be stored.
+	// incoming is already resident when room is needed for a growing update.
+	// It is never a valid victim: when it would be chosen, the next candidate
+	// is returned instead.
+	Victim(incoming string) (string, bool)
+}
+
+// PolicyFactory builds a Policy for a cache holding up to capacity entries. A
+// capacity <= 0 means the cache is bounded by bytes only.
+type PolicyFactory func(capacity int) Policy
+
+// WithPolicy selects the eviction policy of an LRUCache. The default is
+// NewLRUPolicy.
+func WithPolicy(factory PolicyFactory) LRUOption {
+	return func(c *LRUCache) {
+		c.policyFactory = factory
+	}
+}
+
+// lruPolicy evicts the least recently used key
+type lruPolicy struct {
+	list     *list.List
+	elements map[string]*list.Element
+}
+
+// NewLRUPolicy returns a least recently used Policy
+func NewLRUPolicy(capacity int) Policy {
+	return &lruPolicy{
+		list:     list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (p *lruPolicy) Add(key string) {
+	p.elements[key] = p.list.PushFront(key)
+}
+
+func (p *lruPolicy) Touch(key string) {
+	if element, found := p.elements[key]; found {
+		p.list.MoveToFront(element)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	if element, found := p.elements[key]; found {
+		p.list.Remove(element)
+		delete(p.elements, key)
+	}
+}
+
+func (p *lruPolicy) Evict(key string) {
+	p.Remove(key)
+}
+
+func (p *lruPolicy) Victim(incoming string) (string, bool) {
+	return backExcept(p.list, incoming)
+}
+
+// segments records which of a policy's lists each key is on
+type segments map[string]*segmentEntry
+
+type segmentEntry struct {
+	in      *list.List
+	element *list.Element
+}
+
+// of returns the list holding key, or nil
+func (s segments) of(key string) *list.List {
+	if entry, found := s[key]; found {
+		return entry.in
+	}
+	return nil
+}
+
+func (s segments) push(l *list.List, key string) {
+	s[key] = &segmentEntry{in: l, element: l.PushFront(key)}
+}
+
+func (s segments) moveToFront(key string) {
+	if entry, found := s[key]; found {
+		entry.in.MoveToFront(entry.element)
+	}
+}
+
+// unlink removes key from its list and returns that list, or nil
+func (s segments) unlink(key string) *list.List {
+	entry, found := s[key]
+	if !found {
+		return nil
+	}
+	entry.in.Remove(entry.element)
+	delete(s, key)
+	return entry.in
+}
+
+// back returns the key at the tail of l
+func back(l *list.List) (string, bool) {
+	if element := l.Back(); element != nil {
+		return element.Value.(string), true
+	}
+	return "", false
+}
+
+// backExcept returns the key nearest the tail of l other than except
+func backExcept(l *list.List, except string) (string, bool) {
+	for element := l.Back(); element != nil; element = element.Prev() {
+		if key := element.Value.(string); key != except {
+			return key, true
+		}
+	}
+	return "", false
+}
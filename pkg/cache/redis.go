@@ -2,6 +2,13 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -9,41 +16,486 @@ import (
 
 // RedisCache represents a Redis cache
 type RedisCache struct {
-	client *redis.Client
+	client     redis.UniversalClient
+	timeout    time.Duration
+	codec      Codec
+	loads      LoadGroup
+	lockLease  time.Duration
+	lockPrefix string
 }
 
+// DefaultLoadLockPrefix starts the keys of the locks EnableLoadLock takes.
+// The leading NUL byte keeps them apart from keys that clients write.
+const DefaultLoadLockPrefix = "\x00cache:load-lock:"
+
+const loadLockPoll = 25 * time.Millisecond
+
+// releaseLoadLock deletes a load lock only if it still holds our token, so a
+// slow loader never releases a lock that expired and was taken by another
+var releaseLoadLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// compareAndSwapScript replaces KEYS[1] with ARGV[2] only if it holds ARGV[1].
+// ARGV[3] is the TTL in milliseconds, 0 for none.
+var compareAndSwapScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[3] == "0" then
+	redis.call("SET", KEYS[1], ARGV[2])
+else
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return 1`)
+
 // NewRedisCache creates a new RedisCache
 func NewRedisCache(address string) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr: address,
-	})
-	if err := client.Ping(context.Background()).Err(); err != nil {
+	return NewRedisCacheWithOptions(RedisOptions{Addr: address})
+}
+
+// NewRedisCacheWithOptions creates a RedisCache with full control over
+// authentication, TLS and connection pooling, talking to a single server, a
+// Sentinel-managed master or a Redis Cluster as the options select
+func NewRedisCacheWithOptions(opts RedisOptions) (*RedisCache, error) {
+	client, err := opts.newClient()
+	if err != nil {
 		return nil, err
 	}
-	return &RedisCache{client: client}, nil
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, wrapError(ErrBackendUnavailable, err)
+	}
+	return &RedisCache{client: client, codec: JSONCodec{}, lockPrefix: DefaultLoadLockPrefix}, nil
+}
+
+// SetTimeout bounds every operation, including those given a context without
+// a deadline. Zero means no limit. Call it before the cache is shared between
+// goroutines.
+func (c *RedisCache) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetCodec replaces JSONCodec as the encoding of stored values. Values
+// already stored stay readable. Call it before the cache is shared between
+// goroutines.
+func (c *RedisCache) SetCodec(codec Codec) {
+	c.codec = codec
 }
 
 // Set sets a value in the cache with an optional TTL
 func (c *RedisCache) Set(key string, value interface{}, ttl time.Duration) error {
-	return c.client.Set(context.Background(), key, value, ttl).Err()
+	return c.SetCtx(context.Background(), key, value, ttl)
+}
+
+// SetCtx sets a value in the cache, giving up when ctx is done
+func (c *RedisCache) SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return redisError(c.client.Set(ctx, key, data, ttl).Err())
+}
+
+// SetIfMatch sets a value only while the stored value has the given ETag. The
+// key is WATCHed between the read and the write, so a concurrent write makes
+// it fail with ErrVersionMismatch.
+func (c *RedisCache) SetIfMatch(ctx context.Context, key string, value interface{}, ttl time.Duration, etag string) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	err = c.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return ErrVersionMismatch
+		} else if err != nil {
+			return err
+		}
+		currentValue, err := c.codec.Unmarshal(current)
+		if err != nil {
+			return err
+		}
+		if ok, err := matchETag(currentValue, etag); err != nil {
+			return err
+		} else if !ok {
+			return ErrVersionMismatch
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, data, ttl).Err()
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrVersionMismatch
+	}
+	return redisError(err)
+}
+
+// SetNX sets a value only if key is not set, with SET NX
+func (c *RedisCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	stored, err := c.client.SetNX(ctx, key, data, ttl).Result()
+	return stored, redisError(err)
+}
+
+// SetXX sets a value only if key is set, with SET XX
+func (c *RedisCache) SetXX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	stored, err := c.client.SetXX(ctx, key, data, ttl).Result()
+	return stored, redisError(err)
+}
+
+// CompareAndSwap sets new only if key holds old, in one Lua script. The
+// stored bytes are compared with the encoding of old, so with GobCodec values
+// holding maps, whose encoding varies, may not compare equal.
+func (c *RedisCache) CompareAndSwap(ctx context.Context, key string, old, new interface{}, ttl time.Duration) (bool, error) {
+	oldData, err := c.codec.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newData, err := c.codec.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+	var ttlMillis int64
+	if ttl > 0 {
+		ttlMillis = max(ttl.Milliseconds(), 1)
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	swapped, err := compareAndSwapScript.Run(ctx, c.client, []string{key}, oldData, newData, ttlMillis).Int()
+	return swapped == 1, redisError(err)
 }
 
 // Get gets a value from the cache
 func (c *RedisCache) Get(key string) (interface{}, error) {
-	val, err := c.client.Get(context.Background(), key).Result()
+	return c.GetCtx(context.Background(), key)
+}
+
+// GetCtx gets a value from the cache, giving up when ctx is done
+func (c *RedisCache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, redisError(err)
+	}
+	return c.codec.Unmarshal(data)
+}
+
+// Ping checks that Redis answers
+func (c *RedisCache) Ping(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return redisError(c.client.Ping(ctx).Err())
+}
+
+// GetWithTTL gets a value and its remaining TTL in one round trip. Keys
+// without an expiry report a TTL of 0. A key that expires between the GET and
+// the PTTL is reported as ErrNotFound, since it is already gone from Redis.
+func (c *RedisCache) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, redisError(err)
+	}
+	// PTTL answers -2 for a missing key and -1 for a key without an expiry
+	ttl := pttl.Val()
+	switch ttl {
+	case -2:
+		return nil, 0, ErrNotFound
+	case -1:
+		ttl = 0
+	}
+	value, err := c.codec.Unmarshal([]byte(get.Val()))
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, ttl, nil
+}
+
+// SetLoadLockPrefix replaces DefaultLoadLockPrefix as the start of the load
+// lock keys. Keys with this prefix are left out of scans, and an empty prefix
+// restores the default, since the locks would otherwise overwrite the values.
+// Call it before the cache is shared between goroutines.
+func (c *RedisCache) SetLoadLockPrefix(prefix string) {
+	if prefix == "" {
+		prefix = DefaultLoadLockPrefix
+	}
+	c.lockPrefix = prefix
+}
+
+// EnableLoadLock makes GetOrLoad deduplicate loads across processes as well:
+// the first process to miss takes a Redis lock for at most lease while it
+// loads, and the others wait for the value to appear. Call it before the
+// cache is shared between goroutines.
+func (c *RedisCache) EnableLoadLock(lease time.Duration) {
+	c.lockLease = lease
+}
+
+// GetOrLoad returns the cached value for key, calling loader and storing its
+// result on a miss. Concurrent misses for the same key in this process share
+// one loader call, and across processes too when EnableLoadLock was used.
+func (c *RedisCache) GetOrLoad(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	if c.lockLease <= 0 {
+		return getOrLoad(c, &c.loads, key, loader, ttl)
+	}
+	if value, err := c.Get(key); err == nil {
+		return value, nil
+	}
+	return c.loads.Do(key, func() (interface{}, error) {
+		return c.loadWithLock(key, loader, ttl)
+	})
+}
+
+func (c *RedisCache) loadWithLock(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	ctx := context.Background()
+	lockKey := c.lockPrefix + key
+	token, err := newLockToken()
 	if err != nil {
 		return nil, err
 	}
-	return val, nil
+
+	// Give up waiting if the lock keeps changing hands without the value
+	// ever being stored, and load without it
+	deadline := time.Now().Add(2 * c.lockLease)
+	for time.Now().Before(deadline) {
+		if value, err := c.Get(key); err == nil {
+			return value, nil
+		}
+		acquired, err := c.client.SetNX(ctx, lockKey, token, c.lockLease).Result()
+		if err != nil {
+			return nil, redisError(err)
+		}
+		if acquired {
+			defer releaseLoadLock.Run(ctx, c.client, []string{lockKey}, token)
+			// The previous holder may have stored the value between our
+			// read and taking the lock
+			if value, err := c.Get(key); err == nil {
+				return value, nil
+			}
+			return loadAndStore(c, key, loader, ttl)
+		}
+		time.Sleep(loadLockPoll)
+	}
+	return loadAndStore(c, key, loader, ttl)
+}
+
+func newLockToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 // Delete deletes a value from the cache
 func (c *RedisCache) Delete(key string) error {
-	return c.client.Del(context.Background(), key).Err()
+	return c.DeleteCtx(context.Background(), key)
+}
+
+// DeleteCtx deletes a value from the cache, giving up when ctx is done
+func (c *RedisCache) DeleteCtx(ctx context.Context, key string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return redisError(c.client.Del(ctx, key).Err())
 }
 
-// GetAll retrieves all values from the Redis cache (not generally supported)
+// GetAll retrieves up to DefaultScanLimit entries from the Redis cache. Use
+// an Iterator to walk larger keyspaces.
 func (c *RedisCache) GetAll() (map[string]interface{}, error) {
-	// Redis does not support GetAll in the same way as an in-memory cache.
-	return map[string]interface{}{}, nil
+	return c.GetAllCtx(context.Background())
+}
+
+// GetAllCtx is GetAll for callers holding a context
+func (c *RedisCache) GetAllCtx(ctx context.Context) (map[string]interface{}, error) {
+	return Collect(ctx, NewIterator(c, ScanOptions{}))
+}
+
+// Scan returns one page of entries using SCAN, which never blocks the server
+// the way KEYS does, and fetches their values in one round trip. Keys that
+// expire between the two calls are left out.
+//
+// On a Redis Cluster the scan walks the masters one after the other: the top
+// 16 bits of the cursor select the master and the rest is that master's own
+// cursor. Slots migrating during a scan may be missed or seen twice.
+func (c *RedisCache) Scan(ctx context.Context, cursor uint64, opts ScanOptions) (map[string]interface{}, uint64, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		return c.scanNode(ctx, c.client, cursor, opts, false)
+	}
+
+	masters, err := clusterMasters(ctx, cluster)
+	if err != nil {
+		return nil, 0, redisError(err)
+	}
+	shard, nodeCursor := int(cursor>>clusterCursorShift), cursor&clusterCursorMask
+	if shard >= len(masters) {
+		return map[string]interface{}{}, 0, nil
+	}
+	entries, next, err := c.scanNode(ctx, masters[shard], nodeCursor, opts, true)
+	if err != nil {
+		return nil, 0, err
+	}
+	if next > clusterCursorMask {
+		return nil, 0, fmt.Errorf("scan cursor %d of %s does not fit in a cluster cursor", next, masters[shard].Options().Addr)
+	}
+	if next == 0 {
+		if shard++; shard == len(masters) {
+			return entries, 0, nil
+		}
+	}
+	return entries, uint64(shard)<<clusterCursorShift | next, nil
+}
+
+const (
+	clusterCursorShift = 48
+	clusterCursorMask  = 1<<clusterCursorShift - 1
+)
+
+// clusterMasters returns a client for each master, in a stable order
+func clusterMasters(ctx context.Context, cluster *redis.ClusterClient) ([]*redis.Client, error) {
+	var mutex sync.Mutex
+	var masters []*redis.Client
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		mutex.Lock()
+		masters = append(masters, master)
+		mutex.Unlock()
+		return nil
+	})
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].Options().Addr < masters[j].Options().Addr
+	})
+	return masters, err
+}
+
+func (c *RedisCache) scanNode(ctx context.Context, node redis.Cmdable, cursor uint64, opts ScanOptions, pipelined bool) (map[string]interface{}, uint64, error) {
+	match := opts.Match
+	if match == "" {
+		match = "*"
+	}
+	keys, next, err := node.Scan(ctx, cursor, match, int64(opts.count())).Result()
+	if err != nil {
+		return nil, 0, redisError(err)
+	}
+	keys = c.withoutLocks(keys)
+	entries, err := c.getValues(ctx, node, keys, pipelined)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, next, nil
+}
+
+// withoutLocks drops the load lock keys from keys
+func (c *RedisCache) withoutLocks(keys []string) []string {
+	kept := keys[:0]
+	for _, key := range keys {
+		if !strings.HasPrefix(key, c.lockPrefix) {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// GetMulti fetches the values of keys in one round trip per node, leaving
+// out the keys that are not set
+func (c *RedisCache) GetMulti(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	_, cluster := c.client.(*redis.ClusterClient)
+	return c.getValues(ctx, c.client, keys, cluster)
+}
+
+// SetMulti stores every entry with the same TTL in one round trip per node.
+// It is not atomic: on error some entries may have been stored.
+func (c *RedisCache) SetMulti(ctx context.Context, entries map[string]interface{}, ttl time.Duration) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	pipe := c.client.Pipeline()
+	for key, value := range entries {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%q: %w", key, err)
+		}
+		pipe.Set(ctx, key, data, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return redisError(err)
+}
+
+// getValues reads keys with MGET, or with pipelined GETs when the keys may
+// belong to different cluster hash slots, which MGET rejects
+func (c *RedisCache) getValues(ctx context.Context, node redis.Cmdable, keys []string, pipelined bool) (map[string]interface{}, error) {
+	entries := make(map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return entries, nil
+	}
+
+	if !pipelined {
+		values, err := node.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, redisError(err)
+		}
+		for i, value := range values {
+			if str, ok := value.(string); ok {
+				if entries[keys[i]], err = c.codec.Unmarshal([]byte(str)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return entries, nil
+	}
+
+	pipe := node.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, redisError(err)
+	}
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if err != nil {
+			continue
+		}
+		if entries[keys[i]], err = c.codec.Unmarshal(data); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// withTimeout applies the configured operation timeout to ctx
+func (c *RedisCache) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
 }
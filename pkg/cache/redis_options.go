@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisOptions configures the connection of a RedisCache. Zero values keep
+// the go-redis defaults.
+type RedisOptions struct {
+	// Addr is the server address. With MasterName, Addr and Addrs list the
+	// Sentinel nodes; for a cluster they list the seed nodes.
+	Addr  string
+	Addrs []string
+
+	// MasterName selects a Sentinel-managed master with automatic failover
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+
+	// Cluster selects a Redis Cluster client, which is also used whenever
+	// several addresses are given without MasterName. DB is ignored by
+	// clusters. ReadOnly sends reads to replicas.
+	Cluster  bool
+	ReadOnly bool
+
+	// Username selects a Redis 6 ACL user; leave it empty for a plain
+	// requirepass password
+	Username string
+	Password string
+	DB       int
+
+	// TLS enables TLS. TLSCAFile adds a PEM bundle of trusted CAs to verify
+	// the server with, for servers using a private CA.
+	TLS                   bool
+	TLSCAFile             string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	PoolSize     int
+	MinIdleConns int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// MaxRetries is the number of retries of a failed command; -1 disables
+	// retries. Each retry waits between MinRetryBackoff and MaxRetryBackoff.
+	MaxRetries      int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// newClient creates the client for the deployment the options describe
+func (o RedisOptions) newClient() (redis.UniversalClient, error) {
+	addrs := o.Addrs
+	if o.Addr != "" {
+		addrs = append([]string{o.Addr}, addrs...)
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("redis address is required")
+	}
+	tlsConfig, err := o.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	universal := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       o.MasterName,
+		Username:         o.Username,
+		Password:         o.Password,
+		SentinelUsername: o.SentinelUsername,
+		SentinelPassword: o.SentinelPassword,
+		DB:               o.DB,
+		TLSConfig:        tlsConfig,
+		PoolSize:         o.PoolSize,
+		MinIdleConns:     o.MinIdleConns,
+		DialTimeout:      o.DialTimeout,
+		ReadTimeout:      o.ReadTimeout,
+		WriteTimeout:     o.WriteTimeout,
+		MaxRetries:       o.MaxRetries,
+		MinRetryBackoff:  o.MinRetryBackoff,
+		MaxRetryBackoff:  o.MaxRetryBackoff,
+		ReadOnly:         o.ReadOnly,
+	}
+	switch {
+	case o.MasterName != "":
+		return redis.NewFailoverClient(universal.Failover()), nil
+	case o.Cluster || len(addrs) > 1:
+		return redis.NewClusterClient(universal.Cluster()), nil
+	default:
+		return redis.NewClient(universal.Simple()), nil
+	}
+}
+
+func (o RedisOptions) tlsConfig() (*tls.Config, error) {
+	if !o.TLS {
+		return nil, nil
+	}
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.TLSServerName,
+		InsecureSkipVerify: o.TLSInsecureSkipVerify,
+	}
+	if o.TLSCAFile != "" {
+		pem, err := os.ReadFile(o.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in redis CA file %s", o.TLSCAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
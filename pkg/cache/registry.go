@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Registry holds caches under names, remembering the order they were
+// registered in. It is safe for concurrent use.
+type Registry struct {
+	mutex    sync.RWMutex
+	names    []string
+	backends map[string]Cache
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{backends: make(map[string]Cache)}
+}
+
+// Register adds c under name. Names must be unique and non-empty.
+func (r *Registry) Register(name string, c Cache) error {
+	if name == "" {
+		return errors.New("cache name is required")
+	}
+	if c == nil {
+		return fmt.Errorf("cache %q is nil", name)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, found := r.backends[name]; found {
+		return fmt.Errorf("cache %q is already registered", name)
+	}
+	r.names = append(r.names, name)
+	r.backends[name] = c
+	return nil
+}
+
+// Get returns the cache registered under name
+func (r *Registry) Get(name string) (Cache, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	c, found := r.backends[name]
+	return c, found
+}
+
+// Names returns the registered names in registration order
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return append([]string(nil), r.names...)
+}
+
+// Pinger is implemented by caches that can check their backend is reachable
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that c is reachable. Caches that are not Pingers, such as the
+// in-memory ones, are always reachable.
+func Ping(ctx context.Context, c Cache) error {
+	if p, ok := c.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return ctx.Err()
+}
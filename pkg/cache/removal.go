@@ -0,0 +1,38 @@
+package cache
+
+// RemovalReason tells a removal callback why an entry left the cache
+type RemovalReason int
+
+const (
+	// RemovedByEviction means the entry was dropped to respect the entry
+	// capacity or byte budget
+	RemovedByEviction RemovalReason = iota
+	// RemovedByExpiry means the entry's TTL ran out
+	RemovedByExpiry
+	// RemovedByDelete means the entry was deleted explicitly
+	RemovedByDelete
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovedByEviction:
+		return "evicted"
+	case RemovedByExpiry:
+		return "expired"
+	case RemovedByDelete:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
+
+// RemovalFunc is called after an entry has left the cache. It runs outside
+// the cache lock, so it may call back into the cache.
+type RemovalFunc func(key string, value interface{}, reason RemovalReason)
+
+type removal struct {
+	key       string
+	value     interface{}
+	reason    RemovalReason
+	callbacks []RemovalFunc
+}
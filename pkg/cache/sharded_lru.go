@@ -0,0 +1,256 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultShardCount is used by NewShardedLRUCache when shards is not positive.
+const DefaultShardCount = 16
+
+// ShardedLRUCache spreads keys over independent LRUCache segments selected by
+// key hash, so operations on different keys rarely contend for the same mutex.
+// Recency is tracked per shard, which makes eviction approximately LRU.
+type ShardedLRUCache struct {
+	shards []*LRUCache
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+	stop             chan struct{}
+	closeOnce        sync.Once
+}
+
+// NewShardedLRUCache creates a ShardedLRUCache holding at most capacity entries
+// in total, split as evenly as possible across the given number of shards.
+// The options are applied to every shard; a WithMaxBytes budget is divided
+// between them. A value may still be as large as the whole budget: its shard
+// then evicts everything else and holds it alone, over its share. A
+// WithSnapshotFile snapshot covers all shards and its entries
+// are placed by key on load, so it survives a change of the shard count.
+func NewShardedLRUCache(capacity, shards int, opts ...LRUOption) *ShardedLRUCache {
+	if shards <= 0 {
+		shards = DefaultShardCount
+	}
+	if capacity > 0 && shards > capacity {
+		shards = capacity
+	}
+
+	c := &ShardedLRUCache{shards: make([]*LRUCache, shards), stop: make(chan struct{})}
+	for i := range c.shards {
+		shardCapacity := capacity / shards
+		if i < capacity%shards {
+			shardCapacity++
+		}
+		shardOpts := append(append([]LRUOption{}, opts...), c.asShard(shards))
+		c.shards[i] = NewLRUCache(shardCapacity, shardOpts...)
+	}
+	if c.snapshotPath != "" {
+		startSnapshots(c.snapshotPath, c.snapshotInterval, c.stop, c.LoadSnapshotFile, c.SaveSnapshotFile)
+	}
+	return c
+}
+
+// Set sets a value in the shard owning key
+func (c *ShardedLRUCache) Set(key string, value interface{}, ttl time.Duration) error {
+	return c.shard(key).Set(key, value, ttl)
+}
+
+// SetWithRefresh sets a value with a soft and a hard TTL in the shard owning key
+func (c *ShardedLRUCache) SetWithRefresh(key string, value interface{}, refreshAfter, ttl time.Duration) error {
+	return c.shard(key).SetWithRefresh(key, value, refreshAfter, ttl)
+}
+
+// Get gets a value from the shard owning key
+func (c *ShardedLRUCache) Get(key string) (interface{}, error) {
+	return c.shard(key).Get(key)
+}
+
+// Delete deletes a value from the shard owning key
+func (c *ShardedLRUCache) Delete(key string) error {
+	return c.shard(key).Delete(key)
+}
+
+// GetOrLoad gets a value from the shard owning key, loading it on a miss
+func (c *ShardedLRUCache) GetOrLoad(key string, loader LoaderFunc, ttl time.Duration) (interface{}, error) {
+	return c.shard(key).GetOrLoad(key, loader, ttl)
+}
+
+// SetCtx sets a value in the shard owning key
+func (c *ShardedLRUCache) SetCtx(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.shard(key).SetCtx(ctx, key, value, ttl)
+}
+
+// GetCtx gets a value from the shard owning key
+func (c *ShardedLRUCache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	return c.shard(key).GetCtx(ctx, key)
+}
+
+// DeleteCtx deletes a value from the shard owning key
+func (c *ShardedLRUCache) DeleteCtx(ctx context.Context, key string) error {
+	return c.shard(key).DeleteCtx(ctx, key)
+}
+
+// SetIfMatch sets a value in the shard owning key while its ETag matches
+func (c *ShardedLRUCache) SetIfMatch(ctx context.Context, key string, value interface{}, ttl time.Duration, etag string) error {
+	return c.shard(key).SetIfMatch(ctx, key, value, ttl, etag)
+}
+
+// SetNX sets a value in the shard owning key if key is not set
+func (c *ShardedLRUCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.shard(key).SetNX(ctx, key, value, ttl)
+}
+
+// SetXX sets a value in the shard owning key if key is set
+func (c *ShardedLRUCache) SetXX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	return c.shard(key).SetXX(ctx, key, value, ttl)
+}
+
+// CompareAndSwap swaps a value in the shard owning key
+func (c *ShardedLRUCache) CompareAndSwap(ctx context.Context, key string, old, new interface{}, ttl time.Duration) (bool, error) {
+	return c.shard(key).CompareAndSwap(ctx, key, old, new, ttl)
+}
+
+// GetWithTTL gets a value and its remaining TTL from the shard owning key
+func (c *ShardedLRUCache) GetWithTTL(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	return c.shard(key).GetWithTTL(ctx, key)
+}
+
+// GetAllCtx is GetAll for callers holding a context
+func (c *ShardedLRUCache) GetAllCtx(ctx context.Context) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetAll()
+}
+
+// GetAll merges the live entries of every shard. Shards are visited one at a
+// time, so the result is not a point-in-time snapshot of the whole cache.
+func (c *ShardedLRUCache) GetAll() (map[string]interface{}, error) {
+	allItems := make(map[string]interface{})
+	for _, shard := range c.shards {
+		items, err := shard.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range items {
+			allItems[k] = v
+		}
+	}
+	return allItems, nil
+}
+
+// Bytes returns the estimated size of the entries in all shards
+func (c *ShardedLRUCache) Bytes() int64 {
+	var total int64
+	for _, shard := range c.shards {
+		total += shard.Bytes()
+	}
+	return total
+}
+
+// OnEvict registers fn with every shard
+func (c *ShardedLRUCache) OnEvict(fn RemovalFunc) {
+	for _, shard := range c.shards {
+		shard.OnEvict(fn)
+	}
+}
+
+// OnExpire registers fn with every shard
+func (c *ShardedLRUCache) OnExpire(fn RemovalFunc) {
+	for _, shard := range c.shards {
+		shard.OnExpire(fn)
+	}
+}
+
+// OnDelete registers fn with every shard
+func (c *ShardedLRUCache) OnDelete(fn RemovalFunc) {
+	for _, shard := range c.shards {
+		shard.OnDelete(fn)
+	}
+}
+
+// DeleteExpired removes every expired entry from all shards
+func (c *ShardedLRUCache) DeleteExpired() int {
+	removed := 0
+	for _, shard := range c.shards {
+		removed += shard.DeleteExpired()
+	}
+	return removed
+}
+
+// Close stops the background work of every shard and writes the final
+// snapshot when WithSnapshotFile is used. The cache remains usable.
+func (c *ShardedLRUCache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		for _, shard := range c.shards {
+			shard.Close()
+		}
+		if c.snapshotPath != "" {
+			err = c.SaveSnapshotFile(c.snapshotPath)
+		}
+	})
+	return err
+}
+
+// SaveSnapshot writes the live entries of every shard to w as a single
+// snapshot, in the format of LRUCache.SaveSnapshot
+func (c *ShardedLRUCache) SaveSnapshot(w io.Writer) error {
+	now := time.Now()
+	var items []CacheItem
+	for _, shard := range c.shards {
+		items = append(items, shard.liveItems(now)...)
+	}
+	return c.shards[0].writeSnapshot(w, items, now)
+}
+
+// LoadSnapshot adds the entries of a snapshot to the shards owning their keys,
+// whatever the shard count of the cache that wrote it
+func (c *ShardedLRUCache) LoadSnapshot(r io.Reader) error {
+	return c.shards[0].readSnapshot(r, c.SetWithRefresh)
+}
+
+// SaveSnapshotFile atomically replaces the snapshot at path
+func (c *ShardedLRUCache) SaveSnapshotFile(path string) error {
+	return saveSnapshotFile(path, c.SaveSnapshot)
+}
+
+// LoadSnapshotFile loads the snapshot at path
+func (c *ShardedLRUCache) LoadSnapshotFile(path string) error {
+	return loadSnapshotFile(path, c.LoadSnapshot)
+}
+
+func (c *ShardedLRUCache) shard(key string) *LRUCache {
+	return c.shards[fnv32a(key)%uint32(len(c.shards))]
+}
+
+// asShard gives a shard its share of the configured byte budget, while still
+// accepting values up to the whole budget, and moves its snapshot settings to
+// the sharded cache, which snapshots all shards at once
+func (c *ShardedLRUCache) asShard(shards int) LRUOption {
+	return func(shard *LRUCache) {
+		if shard.maxBytes > 0 {
+			shard.maxValueBytes = shard.maxBytes
+			shard.maxBytes = (shard.maxBytes + int64(shards) - 1) / int64(shards)
+		}
+		c.snapshotPath, c.snapshotInterval = shard.snapshotPath, shard.snapshotInterval
+		shard.snapshotPath = ""
+	}
+}
+
+// fnv32a is an allocation-free FNV-1a hash used for shard selection.
+func fnv32a(key string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+	return hash
+}
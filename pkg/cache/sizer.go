@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"reflect"
+)
+
+// Sizer estimates the memory held by a cache entry, in bytes
+type Sizer func(key string, value interface{}) int64
+
+// maxSizeDepth bounds how far DefaultSizer follows pointers and containers,
+// which also protects it against cyclic values.
+const maxSizeDepth = 8
+
+// DefaultSizer counts strings and byte slices exactly and estimates other
+// values by walking them with reflection.
+func DefaultSizer(key string, value interface{}) int64 {
+	size := int64(len(key))
+	switch v := value.(type) {
+	case string:
+		return size + int64(len(v))
+	case []byte:
+		return size + int64(len(v))
+	case nil:
+		return size
+	}
+	return size + sizeOf(reflect.ValueOf(value), 0)
+}
+
+func sizeOf(v reflect.Value, depth int) int64 {
+	size := int64(v.Type().Size())
+	if depth >= maxSizeDepth {
+		return size
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		size += int64(v.Len())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+	case reflect.Array:
+		size = 0
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			size += sizeOf(v.Elem(), depth+1)
+		}
+	case reflect.Struct:
+		size = 0
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOf(v.Field(i), depth+1)
+		}
+	}
+	return size
+}
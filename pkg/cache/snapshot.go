@@ -0,0 +1,304 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Snapshot file layout: the magic bytes and a format version, followed by a
+// gob stream of one snapshotHeader and then one snapshotRecord per entry, from
+// least to most recently used.
+const (
+	snapshotMagic   = "LRUSNAP"
+	snapshotVersion = byte(1)
+)
+
+const (
+	snapshotString byte = iota
+	snapshotBytes
+	snapshotCodec
+	snapshotJSON
+	snapshotBlob
+)
+
+type snapshotHeader struct {
+	Created time.Time
+	Entries int
+}
+
+type snapshotRecord struct {
+	Key          string
+	Kind         byte
+	Value        []byte
+	TTL          time.Duration
+	RefreshAfter time.Duration
+}
+
+// SnapshotCodec encodes values other than strings, byte slices, JSON
+// documents and Blobs, which are stored as-is, into a snapshot
+type SnapshotCodec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// GobSnapshotCodec is the default SnapshotCodec. Concrete types stored behind
+// interface{} must be registered with gob.Register.
+type GobSnapshotCodec struct{}
+
+func (GobSnapshotCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobSnapshotCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// WithSnapshotFile makes the cache warm-start from the snapshot at path, if
+// one exists, and write a new snapshot every interval and on Close. An
+// interval <= 0 only snapshots on Close.
+func WithSnapshotFile(path string, interval time.Duration) LRUOption {
+	return func(c *LRUCache) {
+		c.snapshotPath = path
+		c.snapshotInterval = interval
+	}
+}
+
+// WithSnapshotCodec replaces GobSnapshotCodec for values that are not strings
+// or byte slices
+func WithSnapshotCodec(codec SnapshotCodec) LRUOption {
+	return func(c *LRUCache) {
+		c.snapshotCodec = codec
+	}
+}
+
+// SaveSnapshot writes the live entries with their remaining TTLs and recency
+// order to w
+func (c *LRUCache) SaveSnapshot(w io.Writer) error {
+	now := time.Now()
+	return c.writeSnapshot(w, c.liveItems(now), now)
+}
+
+// liveItems copies the entries that have not expired by now
+func (c *LRUCache) liveItems(now time.Time) []CacheItem {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	items := make([]CacheItem, 0, len(c.items))
+	for _, item := range c.items {
+		if item.expiration.After(now) {
+			items = append(items, *item)
+		}
+	}
+	return items
+}
+
+// writeSnapshot writes items to w from least to most recently used
+func (c *LRUCache) writeSnapshot(w io.Writer, items []CacheItem, now time.Time) error {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].lastAccess.Before(items[j].lastAccess)
+	})
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	if err := bw.WriteByte(snapshotVersion); err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(bw)
+	if err := enc.Encode(snapshotHeader{Created: now, Entries: len(items)}); err != nil {
+		return err
+	}
+	for _, item := range items {
+		record, err := c.snapshotRecord(&item, now)
+		if err != nil {
+			return fmt.Errorf("failed to encode %q: %w", item.key, err)
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadSnapshot adds the entries of a snapshot written by SaveSnapshot to the
+// cache. Entries that expired since the snapshot was taken are skipped.
+func (c *LRUCache) LoadSnapshot(r io.Reader) error {
+	return c.readSnapshot(r, c.SetWithRefresh)
+}
+
+// readSnapshot decodes a snapshot and passes each entry that is still live to
+// set
+func (c *LRUCache) readSnapshot(r io.Reader, set func(key string, value interface{}, refreshAfter, ttl time.Duration) error) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return fmt.Errorf("failed to read snapshot header: %w", err)
+	}
+	if string(magic[:len(snapshotMagic)]) != snapshotMagic {
+		return errors.New("not a cache snapshot")
+	}
+	if version := magic[len(snapshotMagic)]; version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", version)
+	}
+
+	dec := gob.NewDecoder(br)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	elapsed := time.Since(header.Created)
+	for i := 0; i < header.Entries; i++ {
+		var record snapshotRecord
+		if err := dec.Decode(&record); err != nil {
+			return err
+		}
+		// A TTL of 0 marks an entry without expiry
+		ttl := record.TTL
+		if ttl > 0 {
+			if ttl -= elapsed; ttl <= 0 {
+				continue
+			}
+		}
+		value, err := c.snapshotValue(record)
+		if err != nil {
+			return fmt.Errorf("failed to decode %q: %w", record.Key, err)
+		}
+		refreshAfter := record.RefreshAfter
+		if refreshAfter > 0 {
+			// An entry that went stale while we were down is refreshed on
+			// its first read
+			refreshAfter = max(refreshAfter-elapsed, time.Nanosecond)
+		}
+		if err := set(record.Key, value, refreshAfter, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSnapshotFile atomically replaces the snapshot at path
+func (c *LRUCache) SaveSnapshotFile(path string) error {
+	return saveSnapshotFile(path, c.SaveSnapshot)
+}
+
+// LoadSnapshotFile loads the snapshot at path
+func (c *LRUCache) LoadSnapshotFile(path string) error {
+	return loadSnapshotFile(path, c.LoadSnapshot)
+}
+
+// saveSnapshotFile writes a snapshot with save to a temporary file and renames
+// it over path
+func saveSnapshotFile(path string, save func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func loadSnapshotFile(path string, load func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return load(f)
+}
+
+func (c *LRUCache) snapshotRecord(item *CacheItem, now time.Time) (snapshotRecord, error) {
+	record := snapshotRecord{Key: item.key, TTL: item.remaining(now)}
+	if !item.refreshAt.IsZero() {
+		record.RefreshAfter = max(item.refreshAt.Sub(now), time.Nanosecond)
+	}
+
+	switch value := item.value.(type) {
+	case string:
+		record.Kind, record.Value = snapshotString, []byte(value)
+	case []byte:
+		record.Kind, record.Value = snapshotBytes, value
+	case json.RawMessage:
+		record.Kind, record.Value = snapshotJSON, value
+	case Blob:
+		data, err := MarshalValue(value)
+		if err != nil {
+			return record, err
+		}
+		record.Kind, record.Value = snapshotBlob, data
+	default:
+		data, err := c.snapshotCodec.Encode(value)
+		if err != nil {
+			return record, err
+		}
+		record.Kind, record.Value = snapshotCodec, data
+	}
+	return record, nil
+}
+
+func (c *LRUCache) snapshotValue(record snapshotRecord) (interface{}, error) {
+	switch record.Kind {
+	case snapshotString:
+		return string(record.Value), nil
+	case snapshotBytes:
+		return record.Value, nil
+	case snapshotCodec:
+		return c.snapshotCodec.Decode(record.Value)
+	case snapshotJSON:
+		return json.RawMessage(record.Value), nil
+	case snapshotBlob:
+		return UnmarshalValue(record.Value)
+	default:
+		return nil, fmt.Errorf("unknown value kind %d", record.Kind)
+	}
+}
+
+// startSnapshots warm-starts from the snapshot file at path and, with an
+// interval, keeps saving it in the background until stop is closed
+func startSnapshots(path string, interval time.Duration, stop <-chan struct{}, load, save func(path string) error) {
+	if err := load(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("cache: failed to load snapshot %s: %v", path, err)
+	}
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := save(path); err != nil {
+					log.Printf("cache: failed to save snapshot %s: %v", path, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
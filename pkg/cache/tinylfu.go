@@ -0,0 +1,175 @@
+package cache
+
+import "container/list"
+
+// tinyLFUPolicy implements W-TinyLFU (Einziger, Friedman & Manes). New keys
+// enter a small LRU window. When the window overflows, its oldest key must
+// win a frequency contest, judged by a count-min sketch, against the next
+// victim of the main segmented LRU to be kept. The main area is split into
+// probation and protected segments so keys hit once in main cannot push out
+// keys hit repeatedly.
+type tinyLFUPolicy struct {
+	capacity int
+	sketch   *countMinSketch
+
+	window, probation, protected *list.List
+	entries                      segments
+}
+
+const (
+	tinyLFUWindowRatio    = 0.01
+	tinyLFUProtectedRatio = 0.8
+)
+
+// NewTinyLFUPolicy returns a W-TinyLFU Policy
+func NewTinyLFUPolicy(capacity int) Policy {
+	return &tinyLFUPolicy{
+		capacity:  capacity,
+		sketch:    newCountMinSketch(capacity),
+		window:    list.New(),
+		probation: list.New(),
+		protected: list.New(),
+		entries:   make(segments),
+	}
+}
+
+func (p *tinyLFUPolicy) Add(key string) {
+	p.sketch.increment(key)
+	p.entries.push(p.window, key)
+	for p.window.Len() > p.windowSize() {
+		candidate, _ := back(p.window)
+		p.entries.unlink(candidate)
+		p.entries.push(p.probation, candidate)
+	}
+}
+
+func (p *tinyLFUPolicy) Touch(key string) {
+	p.sketch.increment(key)
+	switch p.entries.of(key) {
+	case p.window, p.protected:
+		p.entries.moveToFront(key)
+	case p.probation:
+		p.entries.unlink(key)
+		p.entries.push(p.protected, key)
+		for p.protected.Len() > p.protectedSize() {
+			demoted, _ := back(p.protected)
+			p.entries.unlink(demoted)
+			p.entries.push(p.probation, demoted)
+		}
+	}
+}
+
+func (p *tinyLFUPolicy) Remove(key string) {
+	p.entries.unlink(key)
+}
+
+func (p *tinyLFUPolicy) Evict(key string) {
+	p.entries.unlink(key)
+}
+
+func (p *tinyLFUPolicy) Victim(incoming string) (string, bool) {
+	mainVictim, hasMain := backExcept(p.probation, incoming)
+	if !hasMain {
+		mainVictim, hasMain = backExcept(p.protected, incoming)
+	}
+
+	// Storing a new key pushes the window's oldest key into main, so it has
+	// to compete with main's victim for the remaining space
+	if p.entries.of(incoming) == nil && p.window.Len() >= p.windowSize() {
+		if candidate, ok := back(p.window); ok {
+			if hasMain && p.sketch.estimate(candidate) > p.sketch.estimate(mainVictim) {
+				return mainVictim, true
+			}
+			return candidate, true
+		}
+	}
+	if hasMain {
+		return mainVictim, true
+	}
+	return backExcept(p.window, incoming)
+}
+
+// size stands in for the entry capacity when the cache is bounded by bytes
+func (p *tinyLFUPolicy) size() int {
+	return max(p.capacity, p.window.Len()+p.probation.Len()+p.protected.Len())
+}
+
+func (p *tinyLFUPolicy) windowSize() int {
+	return max(1, int(float64(p.size())*tinyLFUWindowRatio))
+}
+
+func (p *tinyLFUPolicy) protectedSize() int {
+	return int(float64(p.size()-p.windowSize()) * tinyLFUProtectedRatio)
+}
+
+// countMinSketch approximates access frequencies in a fixed amount of memory
+// using four rows of 4-bit saturating counters (stored one per byte). All
+// counters are halved periodically so that old popularity fades.
+type countMinSketch struct {
+	rows      [4][]uint8
+	mask      uint32
+	additions int
+	resetAt   int
+}
+
+const (
+	sketchMinWidth    = 64
+	sketchMaxCount    = 15
+	sketchDefaultSize = 1024
+)
+
+var sketchSeeds = [4]uint32{0x9e3779b1, 0x85ebca77, 0xc2b2ae3d, 0x27d4eb2f}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	if capacity <= 0 {
+		capacity = sketchDefaultSize
+	}
+	width := sketchMinWidth
+	for width < capacity {
+		width <<= 1
+	}
+
+	s := &countMinSketch{mask: uint32(width - 1), resetAt: 10 * width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) increment(key string) {
+	hash := fnv32a(key)
+	for i := range s.rows {
+		if counter := &s.rows[i][s.index(hash, i)]; *counter < sketchMaxCount {
+			*counter++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	hash := fnv32a(key)
+	minimum := uint8(sketchMaxCount)
+	for i := range s.rows {
+		minimum = min(minimum, s.rows[i][s.index(hash, i)])
+	}
+	return minimum
+}
+
+func (s *countMinSketch) index(hash uint32, row int) uint32 {
+	hash *= sketchSeeds[row]
+	hash ^= hash >> 17
+	return hash & s.mask
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,78 @@
+package cache
+
+import "container/list"
+
+// twoQueuePolicy implements the full 2Q algorithm (Johnson & Shasha). New keys
+// enter the a1in FIFO; keys evicted from it are remembered in the a1out ghost
+// queue, and only a key seen again while remembered is promoted to the am LRU.
+// One-off scans therefore never displace the frequently used set.
+type twoQueuePolicy struct {
+	capacity int
+
+	a1in, a1out, am *list.List
+	entries         segments
+}
+
+const (
+	twoQueueInRatio  = 0.25
+	twoQueueOutRatio = 0.5
+)
+
+// New2QPolicy returns a 2Q Policy
+func New2QPolicy(capacity int) Policy {
+	return &twoQueuePolicy{
+		capacity: capacity,
+		a1in:     list.New(),
+		a1out:    list.New(),
+		am:       list.New(),
+		entries:  make(segments),
+	}
+}
+
+func (p *twoQueuePolicy) Add(key string) {
+	if p.entries.of(key) == p.a1out {
+		p.entries.unlink(key)
+		p.entries.push(p.am, key)
+		return
+	}
+	p.entries.push(p.a1in, key)
+}
+
+func (p *twoQueuePolicy) Touch(key string) {
+	// Hits in a1in are treated as correlated references and ignored
+	if p.entries.of(key) == p.am {
+		p.entries.moveToFront(key)
+	}
+}
+
+func (p *twoQueuePolicy) Remove(key string) {
+	p.entries.unlink(key)
+}
+
+func (p *twoQueuePolicy) Evict(key string) {
+	if p.entries.unlink(key) != p.a1in {
+		return
+	}
+	p.entries.push(p.a1out, key)
+	for p.a1out.Len() > max(1, int(float64(p.size())*twoQueueOutRatio)) {
+		ghost, _ := back(p.a1out)
+		p.entries.unlink(ghost)
+	}
+}
+
+func (p *twoQueuePolicy) Victim(incoming string) (string, bool) {
+	if p.a1in.Len() > 0 && (p.a1in.Len() > int(float64(p.size())*twoQueueInRatio) || p.am.Len() == 0) {
+		if key, ok := backExcept(p.a1in, incoming); ok {
+			return key, true
+		}
+	}
+	if key, ok := backExcept(p.am, incoming); ok {
+		return key, true
+	}
+	return backExcept(p.a1in, incoming)
+}
+
+// size stands in for the entry capacity when the cache is bounded by bytes
+func (p *twoQueuePolicy) size() int {
+	return max(p.capacity, p.a1in.Len()+p.am.Len())
+}
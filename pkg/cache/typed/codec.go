@@ -0,0 +1,121 @@
+package typed
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// Codec converts values to and from the bytes stored by remote backends
+type Codec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+// StringCodec stores strings as-is, matching values written by cache.Cache
+// callers
+type StringCodec struct{}
+
+func (StringCodec) Encode(value string) ([]byte, error) { return []byte(value), nil }
+func (StringCodec) Decode(data []byte) (string, error)  { return string(data), nil }
+
+// BytesCodec stores byte slices as-is
+type BytesCodec struct{}
+
+func (BytesCodec) Encode(value []byte) ([]byte, error) { return value, nil }
+func (BytesCodec) Decode(data []byte) ([]byte, error)  { return data, nil }
+
+// JSONCodec stores any JSON-serializable value
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Encode(value V) ([]byte, error) { return json.Marshal(value) }
+
+func (JSONCodec[V]) Decode(data []byte) (V, error) {
+	var value V
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// codecCache adapts a byte-oriented cache.Cache such as RedisCache or
+// MemcachedCache through a Codec
+type codecCache[V any] struct {
+	backend cache.Cache
+	codec   Codec[V]
+}
+
+// NewCodecCache gives a type-safe view of a backend that stores values as
+// strings or bytes, encoding values with codec on the way in and decoding
+// them on the way out
+func NewCodecCache[V any](backend cache.Cache, codec Codec[V]) Cache[string, V] {
+	return &codecCache[V]{backend: backend, codec: codec}
+}
+
+func (c *codecCache[V]) Set(key string, value V, ttl time.Duration) error {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+	return c.backend.Set(key, string(data), ttl)
+}
+
+func (c *codecCache[V]) Get(key string) (V, error) {
+	raw, err := c.backend.Get(key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return c.decode(raw)
+}
+
+func (c *codecCache[V]) Delete(key string) error {
+	return c.backend.Delete(key)
+}
+
+func (c *codecCache[V]) GetAll() (map[string]V, error) {
+	raw, err := c.backend.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]V, len(raw))
+	for key, value := range raw {
+		decoded, err := c.decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %q: %w", key, err)
+		}
+		all[key] = decoded
+	}
+	return all, nil
+}
+
+// decode converts a value read from the backend. Encoded values arrive as
+// strings or bytes, while values the REST API wrote arrive as JSON text
+// (json.RawMessage) or a cache.Blob, whose data is decoded. Any other Go value,
+// such as one read back through a GobCodec, is returned as-is when it already
+// has type V and rejected with ErrTypeMismatch otherwise.
+func (c *codecCache[V]) decode(raw interface{}) (V, error) {
+	var data []byte
+	switch v := raw.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	case cache.Blob:
+		data = v.Data
+	default:
+		if value, ok := raw.(V); ok {
+			return value, nil
+		}
+		var zero V
+		return zero, fmt.Errorf("%w: value is of type %T, not %T or encoded data", cache.ErrTypeMismatch, raw, zero)
+	}
+
+	value, err := c.codec.Decode(data)
+	if err != nil {
+		return value, fmt.Errorf("%w: %w", cache.ErrTypeMismatch, err)
+	}
+	return value, nil
+}
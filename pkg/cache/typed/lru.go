@@ -0,0 +1,119 @@
+package typed
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	expiration time.Time
+}
+
+// LRU is a thread-safe least recently used cache with typed keys and values
+type LRU[K comparable, V any] struct {
+	capacity int
+	items    map[K]*list.Element
+	list     *list.List
+	mutex    sync.Mutex
+}
+
+// NewLRU creates an LRU holding at most capacity entries. A capacity <= 0
+// disables the entry limit.
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*list.Element),
+		list:     list.New(),
+	}
+}
+
+// Set sets a value in the cache. A ttl <= 0 stores it without an expiry, as
+// cache.LRUCache does.
+func (c *LRU[K, V]) Set(key K, value V, ttl time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, found := c.items[key]; found {
+		c.list.MoveToFront(element)
+		item := element.Value.(*entry[K, V])
+		item.value = value
+		item.expiration = expiresAt(ttl)
+		return nil
+	}
+
+	if c.capacity > 0 && c.list.Len() >= c.capacity {
+		if element := c.list.Back(); element != nil {
+			c.remove(element)
+		}
+	}
+
+	c.items[key] = c.list.PushFront(&entry[K, V]{
+		key:        key,
+		value:      value,
+		expiration: expiresAt(ttl),
+	})
+	return nil
+}
+
+func (c *LRU[K, V]) Get(key K) (V, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var zero V
+	element, found := c.items[key]
+	if !found {
+		return zero, cache.ErrNotFound
+	}
+	item := element.Value.(*entry[K, V])
+	if !item.expiration.After(time.Now()) {
+		c.remove(element)
+		return zero, cache.ErrNotFound
+	}
+	c.list.MoveToFront(element)
+	return item.value, nil
+}
+
+func (c *LRU[K, V]) Delete(key K) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, found := c.items[key]; found {
+		c.remove(element)
+		return nil
+	}
+	return cache.ErrNotFound
+}
+
+func (c *LRU[K, V]) GetAll() (map[K]V, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	all := make(map[K]V, len(c.items))
+	for key, element := range c.items {
+		if item := element.Value.(*entry[K, V]); item.expiration.After(now) {
+			all[key] = item.value
+		}
+	}
+	return all, nil
+}
+
+func (c *LRU[K, V]) remove(element *list.Element) {
+	c.list.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
+
+// neverExpires is the expiration of entries stored without a TTL
+var neverExpires = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return neverExpires
+	}
+	return time.Now().Add(ttl)
+}
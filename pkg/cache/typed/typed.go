@@ -0,0 +1,66 @@
+// Package typed provides a type-safe, generics-based view of the cache
+// library. Values come back as V rather than interface{}, so callers no longer
+// need type assertions.
+package typed
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// Cache is the generic counterpart of cache.Cache
+type Cache[K comparable, V any] interface {
+	Set(key K, value V, ttl time.Duration) error
+	Get(key K) (V, error)
+	Delete(key K) error
+	GetAll() (map[K]V, error)
+}
+
+// untyped adapts a cache.Cache holding values of type V
+type untyped[V any] struct {
+	backend cache.Cache
+}
+
+// Wrap gives a type-safe view of a cache.Cache whose values are stored as V,
+// such as an LRUCache. Values of any other type are reported as errors instead
+// of panicking. Use NewCodecCache for backends that store bytes.
+func Wrap[V any](backend cache.Cache) Cache[string, V] {
+	return &untyped[V]{backend: backend}
+}
+
+func (c *untyped[V]) Set(key string, value V, ttl time.Duration) error {
+	return c.backend.Set(key, value, ttl)
+}
+
+func (c *untyped[V]) Get(key string) (V, error) {
+	var zero V
+	raw, err := c.backend.Get(key)
+	if err != nil {
+		return zero, err
+	}
+	value, ok := raw.(V)
+	if !ok {
+		return zero, fmt.Errorf("%w: value is of type %T, not %T", cache.ErrTypeMismatch, raw, zero)
+	}
+	return value, nil
+}
+
+func (c *untyped[V]) Delete(key string) error {
+	return c.backend.Delete(key)
+}
+
+func (c *untyped[V]) GetAll() (map[string]V, error) {
+	raw, err := c.backend.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]V, len(raw))
+	for key, value := range raw {
+		if typedValue, ok := value.(V); ok {
+			all[key] = typedValue
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+	"github.com/gorilla/mux"
+)
+
+// newTestServer serves the REST API over in-memory caches standing in for
+// every backend
+func newTestServer(unifiedCache *api.UnifiedCache) *httptest.Server {
+	r := mux.NewRouter()
+	r.HandleFunc("/cache/{key}", api.HandleCacheRequest(unifiedCache)).Methods("GET", "DELETE", "POST", "PUT")
+	r.HandleFunc("/cache", api.HandleGetAllCacheRequest(unifiedCache)).Methods("GET")
+	r.HandleFunc("/cache", api.HandleDeletePrefixRequest(unifiedCache)).Methods("DELETE")
+	r.HandleFunc("/stats", api.HandleStatsRequest(unifiedCache)).Methods("GET")
+	r.HandleFunc("/backends", api.HandleBackendsRequest(unifiedCache)).Methods("GET")
+	return httptest.NewServer(r)
+}
+
+func mustBackend(t *testing.T, unifiedCache *api.UnifiedCache, name string) cache.Cache {
+	t.Helper()
+	backend, found := unifiedCache.Backend(name)
+	if !found {
+		t.Fatalf("Backend %q is not registered", name)
+	}
+	return backend
+}
+
+func newInMemoryUnifiedCache(opts ...cache.LRUOption) *api.UnifiedCache {
+	return api.NewUnifiedCache(cache.NewLRUCache(10, opts...), cache.NewLRUCache(10, opts...), cache.NewLRUCache(10, opts...))
+}
+
+func TestAPI_SetGetDelete(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/cache/key1", "application/json", bytes.NewBufferString(`{"value":"value1"}`))
+	if err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to set value: %v", resp.Status)
+	}
+
+	for _, cacheType := range []string{"inMemory", "redis", "memcached"} {
+		resp, err = http.Get(srv.URL + "/cache/key1?cache=" + cacheType)
+		if err != nil {
+			t.Fatalf("Failed to get value from %s: %v", cacheType, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Failed to get value from %s: %v", cacheType, resp.Status)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/cache/key1?cache=inMemory", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to delete value: %v", resp.Status)
+	}
+}
+
+func TestAPI_ErrorStatusCodes(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache(cache.WithMaxBytes(16)))
+	defer srv.Close()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"miss", http.MethodGet, "/cache/missing?cache=inMemory", "", http.StatusNotFound},
+		{"invalid cache type", http.MethodGet, "/cache/key?cache=nope", "", http.StatusBadRequest},
+		{"value too large", http.MethodPost, "/cache/key", `{"value":"` + string(bytes.Repeat([]byte("x"), 64)) + `"}`, http.StatusRequestEntityTooLarge},
+		{"delete miss", http.MethodDelete, "/cache/missing?cache=inMemory", "", http.StatusNotFound},
+	}
+
+	client := &http.Client{Timeout: time.Second}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, srv.URL+tc.path, bytes.NewBufferString(tc.body))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
+
+func TestAPI_ScanBackend(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	mustBackend(t, unifiedCache, "inMemory").Set("user:1", "a", time.Minute)
+	mustBackend(t, unifiedCache, "inMemory").Set("user:2", "b", time.Minute)
+	mustBackend(t, unifiedCache, "inMemory").Set("order:1", "c", time.Minute)
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cache?cache=inMemory&match=user:*")
+	if err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to scan: %v", resp.Status)
+	}
+	defer resp.Body.Close()
+
+	var page struct {
+		Entries map[string]interface{} `json:"entries"`
+		Cursor  uint64                 `json:"cursor"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	if len(page.Entries) != 2 || page.Entries["user:1"] != "a" || page.Cursor != 0 {
+		t.Fatalf("Expected the two user entries in a final page, got %+v", page)
+	}
+
+	resp, err = http.Get(srv.URL + "/cache?cache=inMemory&cursor=abc")
+	if err != nil {
+		t.Fatalf("Expected 400 for an invalid cursor, got %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid cursor, got %v", resp.Status)
+	}
+}
+
+func TestAPI_DeletePrefix(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	mustBackend(t, unifiedCache, "inMemory").Set("user:1", "a", time.Minute)
+	mustBackend(t, unifiedCache, "inMemory").Set("order:1", "c", time.Minute)
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/cache?cache=inMemory&prefix=user:", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to delete by prefix: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to delete by prefix: %v", resp.Status)
+	}
+	if _, err := mustBackend(t, unifiedCache, "inMemory").Get("user:1"); err == nil {
+		t.Fatal("Expected user:1 to be deleted")
+	}
+	if _, err := mustBackend(t, unifiedCache, "inMemory").Get("order:1"); err != nil {
+		t.Fatal("Expected order:1 to remain")
+	}
+}
+
+func TestAPI_JSONValues(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache())
+	defer srv.Close()
+
+	for key, value := range map[string]string{
+		"object": `{"name":"Ada","tags":["a","b"]}`,
+		"array":  `[1,2,3]`,
+		"number": `42.5`,
+		"bool":   `true`,
+	} {
+		resp, err := http.Post(srv.URL+"/cache/"+key, "application/json", bytes.NewBufferString(`{"value": `+value+`}`))
+		if err != nil {
+			t.Fatalf("Failed to set %s: %v", key, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Failed to set %s: %v", key, resp.Status)
+		}
+
+		for _, query := range []string{"", "?cache=redis"} {
+			resp, err = http.Get(srv.URL + "/cache/" + key + query)
+			if err != nil {
+				t.Fatalf("Failed to get %s: %v", key, err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Failed to get %s: %v", key, resp.Status)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != value || resp.Header.Get("Content-Type") != "application/json" {
+				t.Fatalf("Expected %s as JSON, got %s (%s)", value, body, resp.Header.Get("Content-Type"))
+			}
+		}
+	}
+
+	resp, err := http.Post(srv.URL+"/cache/missing", "application/json", bytes.NewBufferString(`{"ttl":"1m"}`))
+	if err != nil {
+		t.Fatalf("Expected 400 without a value, got %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without a value, got %v", resp.Status)
+	}
+}
+
+func TestAPI_PutBinaryValue(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache())
+	defer srv.Close()
+
+	image := []byte{0x89, 'P', 'N', 'G', 0, 0xff, 0x10}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/cache/logo", bytes.NewReader(image))
+	req.Header.Set("Content-Type", "image/png")
+	req.Header.Set("X-Cache-TTL", "5m")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to put value: %v", resp.Status)
+	}
+
+	for _, query := range []string{"", "?cache=memcached"} {
+		resp, err = http.Get(srv.URL + "/cache/logo" + query)
+		if err != nil {
+			t.Fatalf("Failed to get value: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Failed to get value: %v", resp.Status)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !bytes.Equal(body, image) {
+			t.Fatalf("Expected the stored bytes, got %v", body)
+		}
+		if resp.Header.Get("Content-Type") != "image/png" || resp.ContentLength != int64(len(image)) {
+			t.Fatalf("Expected image/png of %d bytes, got %s of %d", len(image), resp.Header.Get("Content-Type"), resp.ContentLength)
+		}
+	}
+}
+
+func TestAPI_BodyLimit(t *testing.T) {
+	srv := newTestServer(api.NewUnifiedCache(cache.NewLRUCache(10), cache.NewLRUCache(10), cache.NewLRUCache(10), api.WithMaxBodyBytes(64)))
+	defer srv.Close()
+
+	large := bytes.Repeat([]byte("x"), 100)
+	cases := []struct {
+		name   string
+		method string
+		body   []byte
+		want   int
+	}{
+		{"small post", http.MethodPost, []byte(`{"value":"small"}`), http.StatusOK},
+		{"large post", http.MethodPost, []byte(`{"value":"` + string(large) + `"}`), http.StatusRequestEntityTooLarge},
+		{"small put", http.MethodPut, []byte("small"), http.StatusOK},
+		{"large put", http.MethodPut, large, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, srv.URL+"/cache/key", bytes.NewReader(tc.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
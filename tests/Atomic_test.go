@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestLRUCache_SetNXSetXX(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRUCache(10)
+
+	if stored, err := c.SetXX(ctx, "key1", "value1", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetXX to skip a missing key, got %v %v", stored, err)
+	}
+	if stored, err := c.SetNX(ctx, "key1", "value1", time.Minute); err != nil || !stored {
+		t.Fatalf("Expected SetNX to store a missing key, got %v %v", stored, err)
+	}
+	if stored, err := c.SetNX(ctx, "key1", "value2", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetNX to skip an existing key, got %v %v", stored, err)
+	}
+	if stored, err := c.SetXX(ctx, "key1", "value3", time.Minute); err != nil || !stored {
+		t.Fatalf("Expected SetXX to replace an existing key, got %v %v", stored, err)
+	}
+	if value, _ := c.Get("key1"); value != "value3" {
+		t.Fatalf("Expected value3, got %v", value)
+	}
+
+	c.Set("short", "gone", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if stored, err := c.SetNX(ctx, "short", "again", time.Minute); err != nil || !stored {
+		t.Fatalf("Expected SetNX to treat an expired key as missing, got %v %v", stored, err)
+	}
+}
+
+func TestLRUCache_CompareAndSwapCounter(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRUCache(10)
+	c.Set("counter", 0, 0)
+
+	// Every increment retries until its swap wins, so none is lost
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				old, _ := c.Get("counter")
+				swapped, err := c.CompareAndSwap(ctx, "counter", old, old.(int)+1, 0)
+				if err != nil {
+					t.Errorf("Failed to swap: %v", err)
+					return
+				}
+				if swapped {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := c.Get("counter"); value != 50 {
+		t.Fatalf("Expected 50, got %v", value)
+	}
+}
+
+func TestAPI_AtomicWrites(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	post := func(path, body string) int {
+		resp, err := http.Post(srv.URL+path, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"xx on missing key", "/cache/key1?set=xx", `{"value":"a"}`, http.StatusPreconditionFailed},
+		{"nx on missing key", "/cache/key1?set=nx", `{"value":"a"}`, http.StatusOK},
+		{"nx on existing key", "/cache/key1?set=nx", `{"value":"b"}`, http.StatusConflict},
+		{"xx on existing key", "/cache/key1?set=xx", `{"value":{"n":1}}`, http.StatusOK},
+		{"swap with stale old", "/cache/key1", `{"old":"a","value":{"n":2}}`, http.StatusPreconditionFailed},
+		{"swap with current old", "/cache/key1", `{"old":{ "n": 1 },"value":{"n":2}}`, http.StatusOK},
+		{"unknown set mode", "/cache/key1?set=maybe", `{"value":"c"}`, http.StatusBadRequest},
+		{"two conditions", "/cache/key1?set=xx", `{"old":{"n":2},"value":"c"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if got := post(tc.path, tc.body); got != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+
+	// The swap checked the first tier and then wrote every tier
+	for _, cacheType := range []string{"inMemory", "redis", "memcached"} {
+		value, err := mustBackend(t, unifiedCache, cacheType).Get("key1")
+		if raw, ok := value.(json.RawMessage); err != nil || !ok || string(raw) != `{"n":2}` {
+			t.Fatalf("Expected {\"n\":2} in %s, got %#v %v", cacheType, value, err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{"string", "value1", "value1"},
+		{"string with marker", "\xffCVnot an envelope", "\xffCVnot an envelope"},
+		{"bytes", []byte{0, 1, 2}, []byte{0, 1, 2}},
+		{"raw JSON", json.RawMessage(`{"a":[1,2]}`), json.RawMessage(`{"a":[1,2]}`)},
+		{"number", 42, json.RawMessage(`42`)},
+		{"map", map[string]interface{}{"ok": true}, json.RawMessage(`{"ok":true}`)},
+		{"blob", cache.Blob{ContentType: "image/png", Data: []byte{0x89, 'P'}}, cache.Blob{ContentType: "image/png", Data: []byte{0x89, 'P'}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := cache.MarshalValue(tc.value)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			got, err := cache.UnmarshalValue(data)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			switch want := tc.want.(type) {
+			case string:
+				if got != want {
+					t.Fatalf("Expected %q, got %#v", want, got)
+				}
+			case []byte:
+				if b, ok := got.([]byte); !ok || !bytes.Equal(b, want) {
+					t.Fatalf("Expected %v, got %#v", want, got)
+				}
+			case cache.Blob:
+				if blob, ok := got.(cache.Blob); !ok || blob.ContentType != want.ContentType || !bytes.Equal(blob.Data, want.Data) {
+					t.Fatalf("Expected %+v, got %#v", want, got)
+				}
+			case json.RawMessage:
+				if raw, ok := got.(json.RawMessage); !ok || string(raw) != string(want) {
+					t.Fatalf("Expected %s, got %#v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCodec_PlainStringsStayReadable(t *testing.T) {
+	data, err := cache.MarshalValue("value1")
+	if err != nil || string(data) != "value1" {
+		t.Fatalf("Expected strings to be stored as-is, got %q %v", data, err)
+	}
+
+	// Values written by other clients carry no envelope and read as strings
+	got, err := cache.UnmarshalValue([]byte(`{"written":"elsewhere"}`))
+	if err != nil || got != `{"written":"elsewhere"}` {
+		t.Fatalf("Expected a plain string, got %#v %v", got, err)
+	}
+}
+
+func TestCodec_UnencodableValue(t *testing.T) {
+	if _, err := cache.MarshalValue(make(chan int)); err == nil {
+		t.Fatal("Expected an error for a value JSON cannot encode")
+	}
+}
+
+type codecPoint struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(codecPoint{})
+}
+
+func TestCodec_Gob(t *testing.T) {
+	data, err := cache.GobCodec{}.Marshal(codecPoint{X: 1, Y: 2})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	// Any codec reads what another one wrote
+	got, err := cache.JSONCodec{}.Unmarshal(data)
+	if err != nil || got != (codecPoint{X: 1, Y: 2}) {
+		t.Fatalf("Expected the point back, got %#v %v", got, err)
+	}
+}
+
+func TestCodec_RawRejectsOtherValues(t *testing.T) {
+	if _, err := (cache.RawCodec{}).Marshal(42); !errors.Is(err, cache.ErrTypeMismatch) {
+		t.Fatalf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if data, err := (cache.RawCodec{}).Marshal("value1"); err != nil || string(data) != "value1" {
+		t.Fatalf("Expected strings to be stored as-is, got %q %v", data, err)
+	}
+}
+
+func TestCodec_Compress(t *testing.T) {
+	codec := cache.Compress(cache.JSONCodec{}, 64)
+
+	large := strings.Repeat("compressible ", 100)
+	data, err := codec.Marshal(large)
+	if err != nil || len(data) >= len(large) {
+		t.Fatalf("Expected a compressed value shorter than %d bytes, got %d %v", len(large), len(data), err)
+	}
+	if got, err := cache.UnmarshalValue(data); err != nil || got != large {
+		t.Fatalf("Expected the original string back, got %v", err)
+	}
+
+	data, err = codec.Marshal("small")
+	if err != nil || string(data) != "small" {
+		t.Fatalf("Expected values under the threshold to stay as-is, got %q %v", data, err)
+	}
+}
+
+func TestCodec_Parse(t *testing.T) {
+	for _, name := range []string{"", "json", "gob", "raw"} {
+		if _, err := cache.ParseCodec(name, 0); err != nil {
+			t.Fatalf("Expected codec %q to parse, got %v", name, err)
+		}
+	}
+	if _, err := cache.ParseCodec("msgpack", 0); err == nil {
+		t.Fatal("Expected an error for an unknown codec")
+	}
+}
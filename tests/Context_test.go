@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache/typed"
+)
+
+func TestContextCache_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	backends := map[string]cache.ContextCache{
+		"lru":     cache.NewLRUCache(10),
+		"sharded": cache.NewShardedLRUCache(10, 2),
+	}
+	for name, c := range backends {
+		if err := c.SetCtx(ctx, "key1", "value1", time.Minute); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled from SetCtx, got %v", name, err)
+		}
+		if _, err := c.Get("key1"); !errors.Is(err, cache.ErrNotFound) {
+			t.Errorf("%s: canceled SetCtx stored a value", name)
+		}
+
+		c.Set("key1", "value1", time.Minute)
+		if _, err := c.GetCtx(ctx, "key1"); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled from GetCtx, got %v", name, err)
+		}
+		if err := c.DeleteCtx(ctx, "key1"); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled from DeleteCtx, got %v", name, err)
+		}
+		if _, err := c.GetAllCtx(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled from GetAllCtx, got %v", name, err)
+		}
+	}
+}
+
+func TestContextCache_Bind(t *testing.T) {
+	c := cache.NewLRUCache(10)
+	c.Set("key1", "value1", time.Minute)
+
+	value, err := typed.Wrap[string](cache.Bind(context.Background(), c)).Get("key1")
+	if err != nil || value != "value1" {
+		t.Fatalf("Expected value1 through a bound cache, got %q %v", value, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := typed.Wrap[string](cache.Bind(ctx, c)).Get("key1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled through a canceled bound cache, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// Nothing listens on port 1, so these exercise the unavailable mapping
+// without needing a running server
+func TestErrors_BackendUnavailable(t *testing.T) {
+	if _, err := cache.NewRedisCache("localhost:1"); !errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Errorf("Expected Redis to report ErrBackendUnavailable, got %v", err)
+	}
+	if _, err := cache.NewMemcachedCache("localhost:1"); !errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Errorf("Expected Memcached to report ErrBackendUnavailable, got %v", err)
+	}
+}
+
+func TestErrors_MemcachedClusterUnavailable(t *testing.T) {
+	servers := []cache.MemcachedServer{{Addr: "localhost:1", Weight: 1}, {Addr: "localhost:2", Weight: 2}}
+	if _, err := cache.NewMemcachedCluster(servers); !errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Errorf("Expected a cluster with no live servers to report ErrBackendUnavailable, got %v", err)
+	}
+}
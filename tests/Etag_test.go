@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestETag_DependsOnValue(t *testing.T) {
+	a, _ := cache.ETag("value1")
+	b, _ := cache.ETag("value1")
+	c, _ := cache.ETag("value2")
+	if a != b || a == c || a[0] != '"' {
+		t.Fatalf("Expected equal values to share a quoted ETag, got %s %s %s", a, b, c)
+	}
+}
+
+func TestLRUCache_SetIfMatch(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRUCache(10)
+
+	if err := c.SetIfMatch(ctx, "key1", "value1", time.Minute, cache.AnyETag); !errors.Is(err, cache.ErrVersionMismatch) {
+		t.Fatalf("Expected ErrVersionMismatch for a missing key, got %v", err)
+	}
+
+	c.Set("key1", "value1", time.Minute)
+	etag, _ := cache.ETag("value1")
+	if err := c.SetIfMatch(ctx, "key1", "value2", time.Minute, etag); err != nil {
+		t.Fatalf("Expected the write to succeed, got %v", err)
+	}
+	if err := c.SetIfMatch(ctx, "key1", "value3", time.Minute, etag); !errors.Is(err, cache.ErrVersionMismatch) {
+		t.Fatalf("Expected ErrVersionMismatch for a stale ETag, got %v", err)
+	}
+	if value, _ := c.Get("key1"); value != "value2" {
+		t.Fatalf("Expected value2, got %v", value)
+	}
+}
+
+func TestLRUCache_SetIfMatchOneWinner(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewShardedLRUCache(10, 2)
+	c.Set("key1", "start", time.Minute)
+	etag, _ := cache.ETag("start")
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	won := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if c.SetIfMatch(ctx, "key1", i, time.Minute, etag) == nil {
+				mutex.Lock()
+				won++
+				mutex.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if won != 1 {
+		t.Fatalf("Expected exactly one writer to win, got %d", won)
+	}
+}
+
+func TestAPI_ConditionalRequests(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/cache/key1", "application/json", bytes.NewBufferString(`{"value":"value1"}`))
+	if err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == "" {
+		t.Fatalf("Expected the write to return an ETag: %v", resp.Status)
+	}
+	etag := resp.Header.Get("ETag")
+
+	resp, err = http.Get(srv.URL + "/cache/key1")
+	if err != nil {
+		t.Fatalf("Failed to get value: %v", err)
+	}
+	if resp.Header.Get("ETag") != etag {
+		t.Fatalf("Expected GET to return ETag %s, got %s", etag, resp.Header.Get("ETag"))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/cache/key1?cache=redis", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected 304 for a matching If-None-Match, got %v", err)
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("Expected 304 for a matching If-None-Match, got %v", resp.Status)
+	}
+
+	conditionalPost := func(key, body, ifMatch string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/cache/"+key, bytes.NewBufferString(body))
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to post: %v", err)
+		}
+		return resp
+	}
+
+	if resp := conditionalPost("key1", `{"value":"value2"}`, etag); resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Fatalf("Expected the conditional write to succeed with a new ETag, got %v", resp.Status)
+	}
+	if resp := conditionalPost("key1", `{"value":"value3"}`, etag); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for a stale ETag, got %v", resp.Status)
+	}
+	if resp := conditionalPost("missing", `{"value":"value3"}`, "*"); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 for a missing entry, got %v", resp.Status)
+	}
+	if resp := conditionalPost("key1", `{"value":"value3"}`, `"a", "b"`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an ETag list, got %v", resp.Status)
+	}
+}
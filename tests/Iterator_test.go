@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// pagedScanner serves fixed pages, with the cursor indexing the next page
+type pagedScanner struct {
+	pages []map[string]interface{}
+	err   error
+	calls int
+}
+
+func (s *pagedScanner) Scan(ctx context.Context, cursor uint64, opts cache.ScanOptions) (map[string]interface{}, uint64, error) {
+	s.calls++
+	if s.err != nil && int(cursor) == len(s.pages)-1 {
+		return nil, 0, s.err
+	}
+	next := cursor + 1
+	if int(next) == len(s.pages) {
+		next = 0
+	}
+	return s.pages[cursor], next, nil
+}
+
+func TestIterator_WalksAllPages(t *testing.T) {
+	s := &pagedScanner{pages: []map[string]interface{}{
+		{"a": 1, "b": 2},
+		{},
+		{"c": 3},
+	}}
+
+	entries, err := cache.Collect(context.Background(), cache.NewIterator(s, cache.ScanOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to iterate: %v", err)
+	}
+	if len(entries) != 3 || entries["c"] != 3 {
+		t.Fatalf("Expected entries from every page, got %v", entries)
+	}
+	if s.calls != 3 {
+		t.Fatalf("Expected 3 scan calls, got %d", s.calls)
+	}
+}
+
+func TestIterator_Limit(t *testing.T) {
+	page := make(map[string]interface{})
+	for i := 0; i < 10; i++ {
+		page[fmt.Sprintf("k%d", i)] = i
+	}
+	s := &pagedScanner{pages: []map[string]interface{}{page, page}}
+
+	it := cache.NewIterator(s, cache.ScanOptions{Limit: 4})
+	entries, err := cache.Collect(context.Background(), it)
+	if err != nil {
+		t.Fatalf("Failed to iterate: %v", err)
+	}
+	if len(entries) != 4 || !it.Truncated() {
+		t.Fatalf("Expected 4 entries and a truncated iteration, got %d %v", len(entries), it.Truncated())
+	}
+	if s.calls != 1 {
+		t.Fatalf("Expected the limit to stop further scans, got %d calls", s.calls)
+	}
+}
+
+func TestIterator_Error(t *testing.T) {
+	s := &pagedScanner{
+		pages: []map[string]interface{}{{"a": 1}, {"b": 2}},
+		err:   cache.ErrBackendUnavailable,
+	}
+
+	it := cache.NewIterator(s, cache.ScanOptions{})
+	count := 0
+	for it.Next(context.Background()) {
+		count++
+	}
+	if count != 1 || !errors.Is(it.Err(), cache.ErrBackendUnavailable) {
+		t.Fatalf("Expected one entry then ErrBackendUnavailable, got %d %v", count, it.Err())
+	}
+}
@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestLRUCache_GetOrLoadDeduplicates(t *testing.T) {
+	c := cache.NewLRUCache(10)
+
+	var calls int32
+	loader := func(key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return "loaded-" + key, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.GetOrLoad("hot", loader, time.Minute)
+			if err != nil || value != "loaded-hot" {
+				t.Errorf("Expected loaded-hot, got %v (%v)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected the loader to run once, ran %d times", n)
+	}
+	if value, err := c.Get("hot"); err != nil || value != "loaded-hot" {
+		t.Fatalf("Expected loaded value to be cached, got %v (%v)", value, err)
+	}
+}
+
+func TestLRUCache_GetOrLoadError(t *testing.T) {
+	c := cache.NewLRUCache(10)
+	boom := errors.New("database down")
+
+	_, err := c.GetOrLoad("key", func(string) (interface{}, error) { return nil, boom }, time.Minute)
+	if !errors.Is(err, boom) {
+		t.Fatalf("Expected loader error, got %v", err)
+	}
+	if _, err := c.Get("key"); err == nil {
+		t.Fatal("Expected failed load not to be cached")
+	}
+}
+
+func TestLoadGroup_PanicFailsWaiters(t *testing.T) {
+	var group cache.LoadGroup
+	started, release := make(chan struct{}), make(chan struct{})
+
+	leaderPanic := make(chan interface{})
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		group.Do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("loader bug")
+		})
+	}()
+	<-started
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	waiter := make(chan result)
+	go func() {
+		value, err := group.Do("key", func() (interface{}, error) {
+			// Only reached if the waiter arrived after the leader finished
+			return "own load", nil
+		})
+		waiter <- result{value, err}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if recovered := <-leaderPanic; recovered != "loader bug" {
+		t.Fatalf("Expected the panic to reach the leader, got %v", recovered)
+	}
+	if got := <-waiter; got.value == nil && got.err == nil {
+		t.Fatal("Expected the waiter to get an error, got a nil value")
+	}
+}
+
+func TestShardedLRUCache_GetOrLoadHit(t *testing.T) {
+	c := cache.NewShardedLRUCache(100, 4)
+	c.Set("key", "cached", time.Minute)
+
+	value, err := c.GetOrLoad("key", func(string) (interface{}, error) {
+		t.Fatal("Loader must not run on a hit")
+		return nil, nil
+	}, time.Minute)
+	if err != nil || value != "cached" {
+		t.Fatalf("Expected cached, got %v (%v)", value, err)
+	}
+}
+
+func TestLRUCache_StaleWhileRevalidate(t *testing.T) {
+	var calls int32
+	loader, started, release, _ := blockingLoader()
+	counted := func(key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return loader(key)
+	}
+	c := cache.NewLRUCache(10, cache.WithLoader(counted))
+	c.SetWithRefresh("key", "stale", 50*time.Millisecond, time.Minute)
+
+	if value, _ := c.Get("key"); value != "stale" {
+		t.Fatalf("Expected stale before the soft TTL, got %v", value)
+	}
+
+	// Past the soft TTL the stale value is served while a reload starts
+	waitFor(t, "a background reload", func() bool {
+		if value, err := c.Get("key"); err != nil || value != "stale" {
+			t.Fatalf("Expected stale value to be served, got %v (%v)", value, err)
+		}
+		select {
+		case <-started:
+			return true
+		default:
+			return false
+		}
+	})
+	c.Get("key")
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("Expected one background reload, got %d", n)
+	}
+
+	close(release)
+	waitFor(t, "the refreshed value", func() bool {
+		value, _ := c.Get("key")
+		return value == "reloaded"
+	})
+}
+
+func TestLRUCache_HardTTLIsMiss(t *testing.T) {
+	var calls int32
+	loader := func(key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("unavailable")
+	}
+	c := cache.NewLRUCache(10, cache.WithLoader(loader))
+	c.SetWithRefresh("key", "stale", time.Nanosecond, 500*time.Millisecond)
+
+	// A second reload only starts once the first has failed
+	waitFor(t, "a failed reload", func() bool {
+		if value, err := c.Get("key"); err != nil || value != "stale" {
+			t.Fatalf("Expected stale value while reload fails, got %v (%v)", value, err)
+		}
+		return atomic.LoadInt32(&calls) >= 2
+	})
+
+	waitFor(t, "a miss after the hard TTL", func() bool {
+		_, err := c.Get("key")
+		return err != nil
+	})
+}
+
+func TestLRUCache_RefreshAhead(t *testing.T) {
+	loader := func(key string) (interface{}, error) {
+		return "fresh", nil
+	}
+	c := cache.NewLRUCache(10, cache.WithLoader(loader), cache.WithRefreshAhead(0.05))
+	const ttl = 2 * time.Second
+	expiry := time.Now().Add(ttl)
+	c.Set("key", "old", ttl)
+
+	// Reads past 5% of the TTL reload the entry ahead of its expiry
+	waitFor(t, "a refresh-ahead reload", func() bool {
+		value, _ := c.Get("key")
+		return value == "fresh"
+	})
+	_, remaining, err := c.GetWithTTL(context.Background(), "key")
+	if err != nil || !time.Now().Add(remaining).After(expiry) {
+		t.Fatalf("Expected refreshed entry to outlive the original TTL, got %v left (%v)", remaining, err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after two seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// blockingLoader returns a loader that waits for release before returning
+// "reloaded", and a channel closed once it has returned
+func blockingLoader() (loader cache.LoaderFunc, started <-chan struct{}, release chan<- struct{}, returned <-chan struct{}) {
+	startedCh, releaseCh, returnedCh := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	loader = func(key string) (interface{}, error) {
+		once.Do(func() { close(startedCh) })
+		<-releaseCh
+		defer close(returnedCh)
+		return "reloaded", nil
+	}
+	return loader, startedCh, releaseCh, returnedCh
+}
+
+func TestLRUCache_RefreshDoesNotUndoDelete(t *testing.T) {
+	loader, started, release, returned := blockingLoader()
+	c := cache.NewLRUCache(10, cache.WithLoader(loader))
+	c.SetWithRefresh("key", "stale", time.Nanosecond, time.Minute)
+	time.Sleep(time.Millisecond)
+
+	c.Get("key")
+	<-started
+	c.Delete("key")
+	close(release)
+	<-returned
+
+	// The reload stores its result right after the loader returns
+	time.Sleep(20 * time.Millisecond)
+	if value, err := c.Get("key"); err == nil {
+		t.Fatalf("Expected the deleted key to stay deleted, got %v", value)
+	}
+}
+
+func TestLRUCache_RefreshDoesNotOverwriteSet(t *testing.T) {
+	loader, started, release, returned := blockingLoader()
+	c := cache.NewLRUCache(10, cache.WithLoader(loader))
+	c.SetWithRefresh("key", "stale", time.Nanosecond, time.Minute)
+	time.Sleep(time.Millisecond)
+
+	c.Get("key")
+	<-started
+	c.Set("key", "newer", time.Minute)
+	close(release)
+	<-returned
+
+	time.Sleep(20 * time.Millisecond)
+	if value, err := c.Get("key"); err != nil || value != "newer" {
+		t.Fatalf("Expected the write made during the reload to win, got %v (%v)", value, err)
+	}
+}
@@ -3,6 +3,8 @@
 package tests
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -139,3 +141,207 @@ func TestLRUCache_UpdateValue(t *testing.T) {
 		t.Fatalf("Expected updatedValue, got %v", value)
 	}
 }
+
+func TestLRUCache_SweeperReclaimsExpired(t *testing.T) {
+	cache := cache.NewLRUCache(100, cache.WithSweepInterval(5*time.Millisecond))
+	defer cache.Close()
+
+	for i := 0; i < 50; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), "value", 10*time.Millisecond)
+	}
+	cache.Set("live", "value", time.Minute)
+
+	time.Sleep(50 * time.Millisecond)
+
+	if n := cache.Len(); n != 1 {
+		t.Fatalf("Expected only the live entry to remain, got %d entries", n)
+	}
+}
+
+func TestLRUCache_EvictionPrefersExpired(t *testing.T) {
+	cache := cache.NewLRUCache(2)
+	cache.Set("old", "value", time.Minute)
+	cache.Set("short", "value", 10*time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+	cache.Set("new", "value", time.Minute)
+
+	if _, err := cache.Get("old"); err != nil {
+		t.Fatalf("Expected the live LRU entry to survive eviction: %v", err)
+	}
+	if _, err := cache.Get("new"); err != nil {
+		t.Fatalf("Expected new entry to be stored: %v", err)
+	}
+}
+
+func TestLRUCache_ExpiryWithOneTTL(t *testing.T) {
+	// Entries sharing a TTL are reclaimed without the expiry heap
+	c := cache.NewLRUCache(1)
+	var expired []string
+	c.OnExpire(func(key string, value interface{}, reason cache.RemovalReason) {
+		expired = append(expired, key)
+	})
+	c.Set("a", "value", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	c.Set("b", "value", 10*time.Millisecond)
+	if len(expired) != 1 || expired[0] != "a" {
+		t.Fatalf("Expected the expired victim to be reclaimed as expired, got %v", expired)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if removed := c.DeleteExpired(); removed != 1 || c.Len() != 0 {
+		t.Fatalf("Expected DeleteExpired to reclaim b, removed %d", removed)
+	}
+}
+
+func TestLRUCache_MaxBytes(t *testing.T) {
+	cache := cache.NewLRUCache(0, cache.WithMaxBytes(100))
+
+	for i := 0; i < 10; i++ {
+		// 4 byte key + 16 byte value
+		cache.Set(fmt.Sprintf("k%03d", i), "0123456789abcdef", time.Minute)
+	}
+
+	if used := cache.Bytes(); used > 100 {
+		t.Fatalf("Expected at most 100 bytes in use, got %d", used)
+	}
+	if n := cache.Len(); n != 5 {
+		t.Fatalf("Expected 5 entries to fit the budget, got %d", n)
+	}
+	if _, err := cache.Get("k009"); err != nil {
+		t.Fatalf("Expected most recent entry to be kept: %v", err)
+	}
+	if _, err := cache.Get("k000"); err == nil {
+		t.Fatal("Expected least recent entry to be evicted")
+	}
+
+	if err := cache.Set("big", make([]byte, 200), time.Minute); err == nil {
+		t.Fatal("Expected an error for a value larger than the budget")
+	}
+}
+
+func TestLRUCache_MaxBytesUpdate(t *testing.T) {
+	cache := cache.NewLRUCache(0, cache.WithMaxBytes(50))
+	cache.Set("a", "0123456789", time.Minute)
+	cache.Set("b", "0123456789", time.Minute)
+
+	cache.Set("b", "0123456789012345678901234567890123456789", time.Minute)
+
+	if used := cache.Bytes(); used != 41 {
+		t.Fatalf("Expected 41 bytes in use, got %d", used)
+	}
+	if _, err := cache.Get("a"); err == nil {
+		t.Fatal("Expected a to be evicted after b grew")
+	}
+}
+
+func TestLRUCache_CustomSizer(t *testing.T) {
+	sizer := func(key string, value interface{}) int64 { return 10 }
+	cache := cache.NewLRUCache(0, cache.WithMaxBytes(30), cache.WithSizer(sizer))
+
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), i, time.Minute)
+	}
+	if n := cache.Len(); n != 3 {
+		t.Fatalf("Expected 3 entries, got %d", n)
+	}
+}
+
+func TestLRUCache_RemovalCallbacks(t *testing.T) {
+	c := cache.NewLRUCache(2)
+
+	var mu sync.Mutex
+	reasons := map[string]cache.RemovalReason{}
+	record := func(key string, value interface{}, reason cache.RemovalReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		reasons[key] = reason
+		// Callbacks run outside the lock and may use the cache
+		c.Len()
+	}
+	c.OnEvict(record)
+	c.OnExpire(record)
+	c.OnDelete(record)
+
+	c.Set("evicted", "value", time.Minute)
+	c.Set("deleted", "value", time.Minute)
+	c.Set("expired", "value", 10*time.Millisecond)
+	c.Delete("deleted")
+	time.Sleep(20 * time.Millisecond)
+	c.Get("expired")
+
+	want := map[string]cache.RemovalReason{
+		"evicted": cache.RemovedByEviction,
+		"deleted": cache.RemovedByDelete,
+		"expired": cache.RemovedByExpiry,
+	}
+	for key, reason := range want {
+		if got, ok := reasons[key]; !ok || got != reason {
+			t.Errorf("Expected %v to be %v, got %v (reported %v)", key, reason, got, ok)
+		}
+	}
+}
+
+func TestLRUCache_EvictCallbackValue(t *testing.T) {
+	c := cache.NewLRUCache(1)
+
+	var evictedValue interface{}
+	c.OnEvict(func(key string, value interface{}, reason cache.RemovalReason) {
+		evictedValue = value
+	})
+
+	c.Set("key1", "dirty", time.Minute)
+	c.Set("key2", "value2", time.Minute)
+
+	if evictedValue != "dirty" {
+		t.Fatalf("Expected evicted value dirty, got %v", evictedValue)
+	}
+}
+
+func TestLRUCache_Errors(t *testing.T) {
+	c := cache.NewLRUCache(2, cache.WithMaxBytes(10))
+
+	if _, err := c.Get("missing"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := c.Delete("missing"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := c.Set("key", "a value that is too large", time.Minute); !errors.Is(err, cache.ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge, got %v", err)
+	}
+}
+
+func TestLRUCache_KeysAndDeletePrefix(t *testing.T) {
+	c := cache.NewLRUCache(10)
+	c.Set("user:1", "a", time.Minute)
+	c.Set("user:2", "b", time.Minute)
+	c.Set("order:1", "c", time.Minute)
+
+	keys, _ := c.Keys("user:")
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" {
+		t.Fatalf("Expected [user:1 user:2], got %v", keys)
+	}
+
+	deleted, _ := c.DeletePrefix("user:")
+	if deleted != 2 || c.Len() != 1 {
+		t.Fatalf("Expected 2 deleted and 1 remaining, got %d and %d", deleted, c.Len())
+	}
+}
+
+func TestLRUCache_NoExpiry(t *testing.T) {
+	c := cache.NewLRUCache(2, cache.WithSweepInterval(10*time.Millisecond))
+	c.Set("forever", "value", 0)
+	c.Set("brief", "value", 20*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := c.Get("forever"); err != nil {
+		t.Fatalf("Expected an entry without TTL to stay, got %v", err)
+	}
+	if _, err := c.Get("brief"); err == nil {
+		t.Fatal("Expected the entry with a TTL to expire")
+	}
+	if _, ttl, _ := c.GetWithTTL(context.Background(), "forever"); ttl != 0 {
+		t.Fatalf("Expected a TTL of 0 for an entry without expiry, got %v", ttl)
+	}
+}
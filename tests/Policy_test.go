@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+var policies = map[string]cache.PolicyFactory{
+	"LRU":     cache.NewLRUPolicy,
+	"LFU":     cache.NewLFUPolicy,
+	"ARC":     cache.NewARCPolicy,
+	"2Q":      cache.New2QPolicy,
+	"TinyLFU": cache.NewTinyLFUPolicy,
+}
+
+func TestPolicies_RespectCapacity(t *testing.T) {
+	for name, factory := range policies {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewLRUCache(50, cache.WithPolicy(factory))
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("key%d", i%200)
+				if _, err := c.Get(key); err != nil {
+					c.Set(key, "value", time.Minute)
+				}
+				if i%7 == 0 {
+					c.Delete(fmt.Sprintf("key%d", i%13))
+				}
+			}
+			if n := c.Len(); n > 50 {
+				t.Fatalf("Expected at most 50 entries, got %d", n)
+			}
+		})
+	}
+}
+
+func TestPolicies_ScanResistance(t *testing.T) {
+	for _, name := range []string{"LFU", "ARC", "2Q", "TinyLFU"} {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewLRUCache(100, cache.WithPolicy(policies[name]))
+
+			// Establish a hot set that is read repeatedly among cold misses
+			for round := 0; round < 10; round++ {
+				for i := 0; i < 20; i++ {
+					key := fmt.Sprintf("hot%d", i)
+					if _, err := c.Get(key); err != nil {
+						c.Set(key, "value", time.Minute)
+					}
+				}
+				for i := 0; i < 50; i++ {
+					c.Set(fmt.Sprintf("cold%d-%d", round, i), "value", time.Minute)
+				}
+			}
+
+			// A one-off scan larger than the cache
+			for i := 0; i < 500; i++ {
+				c.Set(fmt.Sprintf("scan%d", i), "value", time.Minute)
+			}
+
+			hits := 0
+			for i := 0; i < 20; i++ {
+				if _, err := c.Get(fmt.Sprintf("hot%d", i)); err == nil {
+					hits++
+				}
+			}
+			if hits < 15 {
+				t.Fatalf("Expected the hot set to survive the scan, only %d/20 hits", hits)
+			}
+		})
+	}
+}
+
+func TestPolicies_WithMaxBytes(t *testing.T) {
+	for name, factory := range policies {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewLRUCache(0, cache.WithMaxBytes(1000), cache.WithPolicy(factory))
+			for i := 0; i < 500; i++ {
+				c.Set(fmt.Sprintf("key%03d", i), "0123456789", time.Minute)
+			}
+			if used := c.Bytes(); used > 1000 {
+				t.Fatalf("Expected at most 1000 bytes in use, got %d", used)
+			}
+		})
+	}
+}
+
+func TestPolicies_WithMaxBytesGrowingKeys(t *testing.T) {
+	for name, factory := range policies {
+		t.Run(name, func(t *testing.T) {
+			c := cache.NewLRUCache(0, cache.WithMaxBytes(300), cache.WithPolicy(factory))
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 20000; i++ {
+				// Resident keys are rewritten with values of varying size, so
+				// the key being written is often the one its policy would evict
+				key := fmt.Sprintf("key%02d", rng.Intn(30))
+				c.Set(key, strings.Repeat("x", 1+rng.Intn(60)), time.Minute)
+				if rng.Intn(3) == 0 {
+					c.Get(key)
+				}
+				if used := c.Bytes(); used > 300 {
+					t.Fatalf("Expected at most 300 bytes in use after %d writes, got %d", i+1, used)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestRedisOptions_InvalidTLS(t *testing.T) {
+	if _, err := cache.NewRedisCacheWithOptions(cache.RedisOptions{
+		Addr:      "localhost:1",
+		TLS:       true,
+		TLSCAFile: filepath.Join(t.TempDir(), "missing.pem"),
+	}); err == nil || errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Fatalf("Expected a CA file error before connecting, got %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, []byte("not a certificate"), 0o600)
+	if _, err := cache.NewRedisCacheWithOptions(cache.RedisOptions{
+		Addr:      "localhost:1",
+		TLS:       true,
+		TLSCAFile: caFile,
+	}); err == nil || errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Fatalf("Expected an invalid CA error before connecting, got %v", err)
+	}
+}
+
+func TestRedisOptions_Unreachable(t *testing.T) {
+	_, err := cache.NewRedisCacheWithOptions(cache.RedisOptions{
+		Addr:        "localhost:1",
+		Password:    "secret",
+		DB:          2,
+		PoolSize:    4,
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	if !errors.Is(err, cache.ErrBackendUnavailable) {
+		t.Fatalf("Expected ErrBackendUnavailable, got %v", err)
+	}
+}
+
+func TestRedisOptions_MissingAddr(t *testing.T) {
+	if _, err := cache.NewRedisCacheWithOptions(cache.RedisOptions{}); err == nil {
+		t.Fatal("Expected an error without an address")
+	}
+}
+
+func TestRedisOptions_ClusterAndSentinelUnreachable(t *testing.T) {
+	for name, opts := range map[string]cache.RedisOptions{
+		"cluster":  {Addrs: []string{"localhost:1", "localhost:2"}, DialTimeout: 100 * time.Millisecond, MaxRetries: -1},
+		"sentinel": {Addrs: []string{"localhost:1"}, MasterName: "mymaster", DialTimeout: 100 * time.Millisecond, MaxRetries: -1},
+	} {
+		if _, err := cache.NewRedisCacheWithOptions(opts); !errors.Is(err, cache.ErrBackendUnavailable) {
+			t.Errorf("%s: expected ErrBackendUnavailable, got %v", name, err)
+		}
+	}
+}
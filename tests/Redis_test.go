@@ -1,7 +1,11 @@
 package tests
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -139,3 +143,171 @@ func TestRedisCache_UpdateValue(t *testing.T) {
 		t.Fatalf("Expected updatedValue, got %v", value)
 	}
 }
+
+func TestRedisCache_GetOrLoadWithLock(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+	c.EnableLoadLock(time.Second)
+	c.Delete("loadkey")
+
+	var mu sync.Mutex
+	calls := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.GetOrLoad("loadkey", func(key string) (interface{}, error) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				return "loaded", nil
+			}, time.Minute)
+			if err != nil || value != "loaded" {
+				t.Errorf("Expected loaded, got %v (%v)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("Expected the loader to run once, ran %d times", calls)
+	}
+}
+
+func TestRedisCache_LoadLockKeysStayHidden(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+	c.EnableLoadLock(time.Second)
+	c.Delete("locked")
+	c.Set("lock:locked", "user value", time.Minute)
+
+	value, err := c.GetOrLoad("locked", func(key string) (interface{}, error) {
+		// The lock is held while the loader runs
+		all, err := c.GetAll()
+		if err != nil {
+			t.Errorf("Failed to list entries: %v", err)
+		}
+		for key := range all {
+			if strings.HasPrefix(key, cache.DefaultLoadLockPrefix) {
+				t.Errorf("Expected the load lock to be left out of GetAll, got %q", key)
+			}
+		}
+		return "loaded", nil
+	}, time.Minute)
+	if err != nil || value != "loaded" {
+		t.Fatalf("Expected loaded, got %v (%v)", value, err)
+	}
+	if value, err := c.Get("lock:locked"); err != nil || value != "user value" {
+		t.Fatalf("Expected the user's lock: key to be untouched, got %v (%v)", value, err)
+	}
+}
+
+func TestRedisCache_ScanGetAll(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+
+	for i := 0; i < 250; i++ {
+		if err := c.Set(fmt.Sprintf("scan:%d", i), "value", time.Minute); err != nil {
+			t.Fatalf("Failed to set value: %v", err)
+		}
+	}
+
+	it := cache.NewIterator(c, cache.ScanOptions{Match: "scan:*", Count: 50})
+	entries, err := cache.Collect(context.Background(), it)
+	if err != nil {
+		t.Fatalf("Failed to scan: %v", err)
+	}
+	if len(entries) != 250 {
+		t.Fatalf("Expected 250 scanned entries, got %d", len(entries))
+	}
+
+	all, err := c.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get all values: %v", err)
+	}
+	if all["scan:0"] != "value" {
+		t.Fatalf("Expected GetAll to include scan:0, got %v", all["scan:0"])
+	}
+}
+
+func TestRedisCache_SetMultiGetMulti(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := c.SetMulti(ctx, map[string]interface{}{"multi:1": "a", "multi:2": "b"}, time.Minute); err != nil {
+		t.Fatalf("Failed to set values: %v", err)
+	}
+	values, err := c.GetMulti(ctx, []string{"multi:1", "multi:2", "multi:missing"})
+	if err != nil || len(values) != 2 || values["multi:2"] != "b" {
+		t.Fatalf("Expected the two stored values, got %v %v", values, err)
+	}
+}
+
+func TestRedisCache_JSONValue(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+
+	if err := c.Set("json:1", json.RawMessage(`{"name":"Ada"}`), time.Minute); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	value, err := c.Get("json:1")
+	raw, ok := value.(json.RawMessage)
+	if err != nil || !ok || string(raw) != `{"name":"Ada"}` {
+		t.Fatalf("Expected the JSON document back, got %#v %v", value, err)
+	}
+}
+
+func TestRedisCache_SetIfMatch(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+
+	ctx := context.Background()
+	c.Set("etag:1", "value1", time.Minute)
+	etag, _ := cache.ETag("value1")
+	if err := c.SetIfMatch(ctx, "etag:1", "value2", time.Minute, etag); err != nil {
+		t.Fatalf("Expected the write to succeed, got %v", err)
+	}
+	if err := c.SetIfMatch(ctx, "etag:1", "value3", time.Minute, etag); !errors.Is(err, cache.ErrVersionMismatch) {
+		t.Fatalf("Expected ErrVersionMismatch for a stale ETag, got %v", err)
+	}
+}
+
+func TestRedisCache_AtomicWrites(t *testing.T) {
+	c, err := cache.NewRedisCache("localhost:6379")
+	if err != nil {
+		t.Fatalf("Failed to create Redis cache: %v", err)
+	}
+
+	ctx := context.Background()
+	c.Delete("atomic:1")
+	if stored, err := c.SetNX(ctx, "atomic:1", "a", time.Minute); err != nil || !stored {
+		t.Fatalf("Expected SetNX to store a missing key, got %v %v", stored, err)
+	}
+	if stored, err := c.SetNX(ctx, "atomic:1", "b", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetNX to skip an existing key, got %v %v", stored, err)
+	}
+	if swapped, err := c.CompareAndSwap(ctx, "atomic:1", "b", "c", time.Minute); err != nil || swapped {
+		t.Fatalf("Expected a swap with a stale old value to fail, got %v %v", swapped, err)
+	}
+	if swapped, err := c.CompareAndSwap(ctx, "atomic:1", "a", "c", 0); err != nil || !swapped {
+		t.Fatalf("Expected the swap to succeed, got %v %v", swapped, err)
+	}
+	if stored, err := c.SetXX(ctx, "atomic:missing", "d", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetXX to skip a missing key, got %v %v", stored, err)
+	}
+}
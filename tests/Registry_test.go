@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// downCache is an in-memory cache whose health check always fails
+type downCache struct {
+	*cache.LRUCache
+}
+
+func (downCache) Ping(ctx context.Context) error {
+	return cache.ErrBackendUnavailable
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := cache.NewRegistry()
+	if err := r.Register("a", cache.NewLRUCache(1)); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	r.Register("b", cache.NewLRUCache(1))
+
+	if err := r.Register("a", cache.NewLRUCache(1)); err == nil {
+		t.Fatal("Expected an error registering a name twice")
+	}
+	if err := r.Register("", cache.NewLRUCache(1)); err == nil {
+		t.Fatal("Expected an error registering an empty name")
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("Expected names in registration order, got %v", names)
+	}
+}
+
+func TestAPI_NamedBackends(t *testing.T) {
+	backends := cache.NewRegistry()
+	backends.Register("local", cache.NewLRUCache(10))
+	backends.Register("sessions", cache.NewLRUCache(10))
+	backends.Register("profiles", cache.NewLRUCache(10))
+	backends.Register("broken", downCache{cache.NewLRUCache(10)})
+	unifiedCache := api.NewUnifiedCacheFromRegistry(backends, api.WithTierOrder("local", "sessions", "profiles"))
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	mustBackend(t, unifiedCache, "profiles").Set("key1", "value1", time.Minute)
+	resp, err := http.Get(srv.URL + "/cache/key1?cache=profiles")
+	if err != nil {
+		t.Fatalf("Failed to get value from a named backend: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to get value from a named backend: %v", resp.Status)
+	}
+	resp, err = http.Get(srv.URL + "/cache/key1?cache=redis")
+	if err != nil {
+		t.Fatalf("Expected 400 for an unregistered backend, got %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unregistered backend, got %v", resp.Status)
+	}
+
+	resp, err = http.Get(srv.URL + "/backends")
+	if err != nil {
+		t.Fatalf("Failed to list backends: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to list backends: %v", resp.Status)
+	}
+	defer resp.Body.Close()
+	var statuses []api.BackendStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		t.Fatalf("Failed to decode backends: %v", err)
+	}
+	if len(statuses) != 4 || statuses[0].Name != "local" || statuses[0].Type != "lru" || !statuses[0].Healthy {
+		t.Fatalf("Unexpected backend list %+v", statuses)
+	}
+	if statuses[3].Name != "broken" || statuses[3].Healthy || statuses[3].Error == "" {
+		t.Fatalf("Expected the broken backend to be unhealthy, got %+v", statuses[3])
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestShardedLRUCache_SetGetDelete(t *testing.T) {
+	cache := cache.NewShardedLRUCache(64, 8)
+	cache.Set("key1", "value1", time.Minute)
+
+	value, err := cache.Get("key1")
+	if err != nil || value != "value1" {
+		t.Fatalf("Expected value1, got %v", value)
+	}
+
+	if err := cache.Delete("key1"); err != nil {
+		t.Fatalf("Failed to delete key: %v", err)
+	}
+	if _, err := cache.Get("key1"); err == nil {
+		t.Fatal("Expected an error for a deleted key")
+	}
+}
+
+func TestShardedLRUCache_Capacity(t *testing.T) {
+	capacity := 100
+	cache := cache.NewShardedLRUCache(capacity, 8)
+
+	for i := 0; i < 1000; i++ {
+		cache.Set(fmt.Sprintf("key%d", i), "value", time.Minute)
+	}
+
+	all, err := cache.GetAll()
+	if err != nil {
+		t.Fatalf("Failed to get all entries: %v", err)
+	}
+	if len(all) > capacity {
+		t.Fatalf("Expected at most %d entries, got %d", capacity, len(all))
+	}
+}
+
+func TestShardedLRUCache_SmallCapacity(t *testing.T) {
+	cache := cache.NewShardedLRUCache(2, 16)
+	cache.Set("key1", "value1", time.Minute)
+	cache.Set("key2", "value2", time.Minute)
+	cache.Set("key3", "value3", time.Minute)
+
+	all, _ := cache.GetAll()
+	if len(all) > 2 {
+		t.Fatalf("Expected at most 2 entries, got %d", len(all))
+	}
+}
+
+func TestShardedLRUCache_Concurrency(t *testing.T) {
+	cache := cache.NewShardedLRUCache(10000, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", i)
+			if err := cache.Set(key, "value", time.Minute); err != nil {
+				t.Errorf("Failed to set %v: %v", key, err)
+				return
+			}
+			if value, err := cache.Get(key); err != nil || value != "value" {
+				t.Errorf("Expected value for %v, got %v (%v)", key, value, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestShardedLRUCache_ValueLargerThanShardShare(t *testing.T) {
+	c := cache.NewShardedLRUCache(1000, 16, cache.WithMaxBytes(1600))
+
+	// Each shard's share is 100 bytes, but a value may use the whole budget
+	large := strings.Repeat("x", 1000)
+	if err := c.Set("large", large, time.Minute); err != nil {
+		t.Fatalf("Expected a value within the total budget to be stored, got %v", err)
+	}
+	if value, err := c.Get("large"); err != nil || value != large {
+		t.Fatalf("Expected the large value back, got %v", err)
+	}
+
+	if err := c.Set("huge", strings.Repeat("x", 2000), time.Minute); !errors.Is(err, cache.ErrValueTooLarge) {
+		t.Fatalf("Expected ErrValueTooLarge beyond the total budget, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+type snapshotPoint struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(snapshotPoint{})
+}
+
+func TestLRUCache_SnapshotRoundTrip(t *testing.T) {
+	src := cache.NewLRUCache(10)
+	src.Set("text", "value", time.Minute)
+	src.Set("blob", []byte{1, 2, 3}, time.Minute)
+	src.Set("point", snapshotPoint{X: 1, Y: 2}, time.Minute)
+	src.Set("short", "gone", 10*time.Millisecond)
+	src.Set("forever", "kept", 0)
+	src.Set("image", cache.Blob{ContentType: "image/png", Data: []byte{4, 5}}, time.Minute)
+
+	var buf bytes.Buffer
+	if err := src.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	dst := cache.NewLRUCache(10)
+	if err := dst.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	if value, err := dst.Get("text"); err != nil || value != "value" {
+		t.Fatalf("Expected value, got %v (%v)", value, err)
+	}
+	if value, err := dst.Get("blob"); err != nil || !bytes.Equal(value.([]byte), []byte{1, 2, 3}) {
+		t.Fatalf("Expected blob bytes, got %v (%v)", value, err)
+	}
+	if value, err := dst.Get("point"); err != nil || value != (snapshotPoint{X: 1, Y: 2}) {
+		t.Fatalf("Expected point, got %v (%v)", value, err)
+	}
+	if value, err := dst.Get("image"); err != nil || value.(cache.Blob).ContentType != "image/png" {
+		t.Fatalf("Expected blob with its content type, got %v (%v)", value, err)
+	}
+	if _, err := dst.Get("short"); err == nil {
+		t.Fatal("Expected entry that expired since the snapshot to be skipped")
+	}
+	if _, ttl, err := dst.GetWithTTL(context.Background(), "forever"); err != nil || ttl != 0 {
+		t.Fatalf("Expected entry without expiry to stay without expiry, got %v (%v)", ttl, err)
+	}
+}
+
+func TestLRUCache_SnapshotKeepsRecency(t *testing.T) {
+	src := cache.NewLRUCache(3)
+	for i := 0; i < 3; i++ {
+		src.Set(fmt.Sprintf("key%d", i), "value", time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	src.Get("key0")
+
+	var buf bytes.Buffer
+	if err := src.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+	dst := cache.NewLRUCache(3)
+	if err := dst.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	// key1 is now the least recently used and is evicted first
+	dst.Set("key3", "value", time.Minute)
+	if _, err := dst.Get("key1"); err == nil {
+		t.Fatal("Expected key1 to be evicted")
+	}
+	if _, err := dst.Get("key0"); err != nil {
+		t.Fatal("Expected recently used key0 to survive")
+	}
+}
+
+func TestLRUCache_SnapshotFileWarmRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	first := cache.NewLRUCache(10, cache.WithSnapshotFile(path, 0))
+	first.Set("key1", "value1", time.Minute)
+	if err := first.Close(); err != nil {
+		t.Fatalf("Failed to write snapshot on close: %v", err)
+	}
+
+	second := cache.NewLRUCache(10, cache.WithSnapshotFile(path, 0))
+	defer second.Close()
+	if value, err := second.Get("key1"); err != nil || value != "value1" {
+		t.Fatalf("Expected warm restart to restore value1, got %v (%v)", value, err)
+	}
+}
+
+func TestLRUCache_SnapshotRejectsGarbage(t *testing.T) {
+	c := cache.NewLRUCache(10)
+	if err := c.LoadSnapshot(bytes.NewBufferString("definitely not a snapshot")); err == nil {
+		t.Fatal("Expected an error for an invalid snapshot")
+	}
+}
+
+func TestShardedLRUCache_SnapshotSurvivesShardCountChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	first := cache.NewShardedLRUCache(100, 4, cache.WithSnapshotFile(path, 0))
+	for i := 0; i < 50; i++ {
+		first.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), time.Minute)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Failed to write snapshot on close: %v", err)
+	}
+
+	second := cache.NewShardedLRUCache(100, 7, cache.WithSnapshotFile(path, 0))
+	defer second.Close()
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if value, err := second.Get(key); err != nil || value != fmt.Sprintf("value%d", i) {
+			t.Fatalf("Expected %s to be restored after resharding, got %v (%v)", key, value, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func TestTiered_ReadThroughBackfill(t *testing.T) {
+	l1, l2, l3 := cache.NewLRUCache(10), cache.NewLRUCache(10), cache.NewLRUCache(10)
+	u := api.NewUnifiedCache(l1, l2, l3)
+	l3.Set("key1", "value1", 30*time.Second)
+
+	value, err := u.GetTiered(context.Background(), "key1")
+	if err != nil || value != "value1" {
+		t.Fatalf("Expected value1 from the last tier, got %v %v", value, err)
+	}
+
+	for name, tier := range map[string]*cache.LRUCache{"L1": l1, "L2": l2} {
+		_, ttl, err := tier.GetWithTTL(context.Background(), "key1")
+		if err != nil {
+			t.Fatalf("Expected %s to be backfilled, got %v", name, err)
+		}
+		if ttl > 30*time.Second || ttl < 29*time.Second {
+			t.Fatalf("Expected %s to get the remaining TTL, got %v", name, ttl)
+		}
+	}
+
+	stats := u.Stats()
+	if stats["memcached"].Hits != 1 || stats["inMemory"].Misses != 1 || stats["inMemory"].Backfills != 1 {
+		t.Fatalf("Unexpected tier stats %+v", stats)
+	}
+}
+
+func TestTiered_CustomOrderAndMiss(t *testing.T) {
+	l1, l2, l3 := cache.NewLRUCache(10), cache.NewLRUCache(10), cache.NewLRUCache(10)
+	u := api.NewUnifiedCache(l1, l2, l3, api.WithTierOrder("inMemory", "memcached"))
+	l2.Set("key1", "value1", time.Minute)
+
+	if _, err := u.GetTiered(context.Background(), "key1"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Expected a tier left out of the order to be skipped, got %v", err)
+	}
+	if _, found := u.Stats()["redis"]; found {
+		t.Fatal("Expected no stats for a tier left out of the order")
+	}
+}
+
+func TestTiered_GetOrLoadReadsLowerTiers(t *testing.T) {
+	l1, l2, l3 := cache.NewLRUCache(10), cache.NewLRUCache(10), cache.NewLRUCache(10)
+	u := api.NewUnifiedCache(l1, l2, l3)
+	l2.Set("key1", "from-l2", time.Minute)
+
+	value, err := u.GetOrLoad("key1", func(string) (interface{}, error) {
+		t.Fatal("Loader must not run while a lower tier holds the value")
+		return "from-db", nil
+	}, time.Minute)
+	if err != nil || value != "from-l2" {
+		t.Fatalf("Expected from-l2, got %v (%v)", value, err)
+	}
+	if value, err := l1.Get("key1"); err != nil || value != "from-l2" {
+		t.Fatalf("Expected L1 to be backfilled, got %v (%v)", value, err)
+	}
+	if value, err := l2.Get("key1"); err != nil || value != "from-l2" {
+		t.Fatalf("Expected L2 to keep its value, got %v (%v)", value, err)
+	}
+
+	value, err = u.GetOrLoad("key2", func(string) (interface{}, error) { return "from-db", nil }, time.Minute)
+	if err != nil || value != "from-db" {
+		t.Fatalf("Expected from-db on a miss in every tier, got %v (%v)", value, err)
+	}
+	if value, err := l3.Get("key2"); err != nil || value != "from-db" {
+		t.Fatalf("Expected the loaded value in every tier, got %v (%v)", value, err)
+	}
+}
+
+func TestAPI_TieredGet(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	mustBackend(t, unifiedCache, "redis").Set("key1", "value1", time.Minute)
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cache/key1")
+	if err != nil {
+		t.Fatalf("Failed to get value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to get value: %v", resp.Status)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "value1" {
+		t.Fatalf("Expected value1, got %q", body)
+	}
+
+	resp, err = http.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to get stats: %v", resp.Status)
+	}
+	defer resp.Body.Close()
+	var stats map[string]api.TierStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats["redis"].Hits != 1 || stats["inMemory"].Backfills != 1 {
+		t.Fatalf("Unexpected tier stats %+v", stats)
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+func postTTL(t *testing.T, url, body string, headers map[string]string) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/cache/key1", bytes.NewBufferString(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAPI_RequestTTL(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+	inMemory := mustBackend(t, unifiedCache, "inMemory").(*cache.LRUCache)
+
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	cases := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		want    time.Duration
+	}{
+		{"default", `{"value":"v"}`, nil, time.Minute},
+		{"duration string", `{"value":"v","ttl":"90s"}`, nil, 90 * time.Second},
+		{"seconds", `{"value":"v","ttl":300}`, nil, 300 * time.Second},
+		{"absolute expiry", `{"value":"v","expiresAt":` + strconv.FormatInt(expiresAt, 10) + `}`, nil, time.Hour},
+		{"header", `{"value":"v"}`, map[string]string{"X-Cache-TTL": "10m"}, 10 * time.Minute},
+		{"body over header", `{"value":"v","ttl":"20s"}`, map[string]string{"X-Cache-TTL": "10m"}, 20 * time.Second},
+		{"no expiry", `{"value":"v","ttl":"never"}`, nil, 0},
+	}
+	for _, tc := range cases {
+		if status := postTTL(t, srv.URL, tc.body, tc.headers); status != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tc.name, status)
+		}
+		_, ttl, err := inMemory.GetWithTTL(context.Background(), "key1")
+		if err != nil {
+			t.Fatalf("%s: failed to get value: %v", tc.name, err)
+		}
+		if ttl > tc.want || ttl < tc.want-2*time.Second {
+			t.Fatalf("%s: expected a TTL of %v, got %v", tc.name, tc.want, ttl)
+		}
+	}
+}
+
+func TestAPI_RequestTTLValidation(t *testing.T) {
+	unifiedCache := api.NewUnifiedCache(cache.NewLRUCache(10), cache.NewLRUCache(10), cache.NewLRUCache(10),
+		api.WithTTLLimits(time.Second, time.Hour))
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	for name, body := range map[string]string{
+		"below minimum":    `{"value":"v","ttl":"100ms"}`,
+		"above maximum":    `{"value":"v","ttl":"2h"}`,
+		"no expiry capped": `{"value":"v","ttl":"never"}`,
+		"negative":         `{"value":"v","ttl":-5}`,
+		"garbage":          `{"value":"v","ttl":"soon"}`,
+		"past expiry":      `{"value":"v","expiresAt":"2000-01-01T00:00:00Z"}`,
+		"ttl and expiry":   `{"value":"v","ttl":"1m","expiresAt":"2100-01-01T00:00:00Z"}`,
+	} {
+		if status := postTTL(t, srv.URL, body, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, status)
+		}
+	}
+}
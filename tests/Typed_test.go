@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache/typed"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestTypedLRU_SetGet(t *testing.T) {
+	c := typed.NewLRU[int, user](2)
+	c.Set(1, user{Name: "ada", Age: 36}, time.Minute)
+	c.Set(2, user{Name: "alan", Age: 41}, time.Minute)
+	c.Set(3, user{Name: "grace", Age: 85}, time.Minute)
+
+	if _, err := c.Get(1); err == nil {
+		t.Fatal("Expected an error for an evicted key")
+	}
+	u, err := c.Get(3)
+	if err != nil || u.Name != "grace" {
+		t.Fatalf("Expected grace, got %v (%v)", u, err)
+	}
+
+	all, _ := c.GetAll()
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(all))
+	}
+}
+
+func TestTypedLRU_ZeroTTLNeverExpires(t *testing.T) {
+	c := typed.NewLRU[string, int](10)
+	c.Set("forever", 1, 0)
+	c.Set("updated", 2, time.Minute)
+	c.Set("updated", 3, 0)
+
+	for key, want := range map[string]int{"forever": 1, "updated": 3} {
+		if value, err := c.Get(key); err != nil || value != want {
+			t.Fatalf("Expected %d for %s without a TTL, got %v (%v)", want, key, value, err)
+		}
+	}
+}
+
+func TestTyped_WrapTypeMismatch(t *testing.T) {
+	backend := cache.NewLRUCache(10)
+	backend.Set("number", 42, time.Minute)
+	backend.Set("text", "hello", time.Minute)
+
+	strings := typed.Wrap[string](backend)
+	if value, err := strings.Get("text"); err != nil || value != "hello" {
+		t.Fatalf("Expected hello, got %v (%v)", value, err)
+	}
+	if _, err := strings.Get("number"); err == nil {
+		t.Fatal("Expected a type mismatch error")
+	}
+}
+
+func TestTyped_CodecCache(t *testing.T) {
+	backend := cache.NewLRUCache(10)
+	users := typed.NewCodecCache[user](backend, typed.JSONCodec[user]{})
+
+	if err := users.Set("u1", user{Name: "ada", Age: 36}, time.Minute); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	raw, _ := backend.Get("u1")
+	if raw != `{"name":"ada","age":36}` {
+		t.Fatalf("Expected JSON in the backend, got %v", raw)
+	}
+
+	u, err := users.Get("u1")
+	if err != nil || u != (user{Name: "ada", Age: 36}) {
+		t.Fatalf("Expected ada, got %v (%v)", u, err)
+	}
+}
+
+func TestTyped_CodecCacheReadsAPIValues(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/cache/posted", "application/json", bytes.NewBufferString(`{"value":{"name":"ada","age":36}}`))
+	if err != nil {
+		t.Fatalf("Failed to post value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to post value: %v", resp.Status)
+	}
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/cache/put", bytes.NewBufferString(`{"name":"alan","age":41}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		t.Fatalf("Failed to put value: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to put value: %v", resp.Status)
+	}
+
+	backend := mustBackend(t, unifiedCache, "redis")
+	backend.Set("native", user{Name: "grace", Age: 85}, time.Minute)
+	backend.Set("number", 42, time.Minute)
+	users := typed.NewCodecCache[user](backend, typed.JSONCodec[user]{})
+
+	for key, want := range map[string]user{
+		"posted": {Name: "ada", Age: 36},
+		"put":    {Name: "alan", Age: 41},
+		"native": {Name: "grace", Age: 85},
+	} {
+		if u, err := users.Get(key); err != nil || u != want {
+			t.Fatalf("Expected %v for %s, got %v (%v)", want, key, u, err)
+		}
+	}
+	if _, err := users.Get("number"); !errors.Is(err, cache.ErrTypeMismatch) {
+		t.Fatalf("Expected a type mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/api"
+	"github.com/Preethi0716/Cache-Library/preethi/restapi/pkg/cache"
+)
+
+// newPartialFailureCache returns caches whose Redis tier rejects values
+// larger than a few bytes
+func newPartialFailureCache(opts ...api.UnifiedOption) *api.UnifiedCache {
+	return api.NewUnifiedCache(
+		cache.NewLRUCache(10),
+		cache.NewLRUCache(10, cache.WithMaxBytes(16)),
+		cache.NewLRUCache(10),
+		opts...,
+	)
+}
+
+func postWithPolicy(t *testing.T, url, policy string) (int, api.WriteReport) {
+	t.Helper()
+	body := `{"value":"` + strings.Repeat("x", 32) + `"}`
+	resp, err := http.Post(url+"/cache/key1?write="+policy, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var report api.WriteReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode write report: %v", err)
+	}
+	return resp.StatusCode, report
+}
+
+func TestWritePolicy_AllRollsBack(t *testing.T) {
+	unifiedCache := newPartialFailureCache()
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	status, report := postWithPolicy(t, srv.URL, "all")
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", status)
+	}
+	if !report.Tiers[0].OK || !report.Tiers[0].RolledBack || report.Tiers[1].OK {
+		t.Fatalf("Expected inMemory rolled back and redis failed, got %+v", report.Tiers)
+	}
+	if _, err := mustBackend(t, unifiedCache, "inMemory").Get("key1"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Expected the compensating delete to remove key1, got %v", err)
+	}
+}
+
+func TestWritePolicy_BestEffortAndQuorum(t *testing.T) {
+	for _, policy := range []string{"best-effort", "quorum"} {
+		srv := newTestServer(newPartialFailureCache())
+
+		status, report := postWithPolicy(t, srv.URL, policy)
+		if status != http.StatusOK || report.Policy != policy {
+			t.Fatalf("%s: expected 200, got %d %+v", policy, status, report)
+		}
+		if !report.Tiers[0].OK || report.Tiers[1].OK || report.Tiers[1].Error == "" || !report.Tiers[2].OK {
+			t.Fatalf("%s: expected only redis to fail, got %+v", policy, report.Tiers)
+		}
+		srv.Close()
+	}
+}
+
+func TestWritePolicy_QuorumMissed(t *testing.T) {
+	unifiedCache := api.NewUnifiedCache(
+		cache.NewLRUCache(10),
+		cache.NewLRUCache(10, cache.WithMaxBytes(16)),
+		cache.NewLRUCache(10, cache.WithMaxBytes(16)),
+		api.WithWritePolicy(api.WriteQuorum),
+	)
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	status, report := postWithPolicy(t, srv.URL, "")
+	if status == http.StatusOK || report.Policy != "quorum" || report.Error == "" {
+		t.Fatalf("Expected a missed quorum to fail, got %d %+v", status, report)
+	}
+}
+
+func TestWritePolicy_PrimaryFansOut(t *testing.T) {
+	unifiedCache := newInMemoryUnifiedCache()
+	srv := newTestServer(unifiedCache)
+	defer srv.Close()
+
+	status, report := postWithPolicy(t, srv.URL, "primary")
+	if status != http.StatusOK || !report.Tiers[0].OK || !report.Tiers[1].Pending || !report.Tiers[2].Pending {
+		t.Fatalf("Expected a primary write with pending fan-out, got %d %+v", status, report)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := mustBackend(t, unifiedCache, "memcached").Get("key1"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the fan-out to reach the last tier")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWritePolicy_Invalid(t *testing.T) {
+	srv := newTestServer(newInMemoryUnifiedCache())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/cache/key1?write=sometimes", "application/json", bytes.NewBufferString(`{"value":"v"}`))
+	if err != nil {
+		t.Fatalf("Expected 400 for an unknown write policy, got %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unknown write policy, got %v", resp.Status)
+	}
+}
@@ -1,6 +1,16 @@
 package tests
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -47,26 +57,26 @@ func TestMemcachedCache_Delete(t *testing.T) {
 }
 
 func TestMemcachedCache_TTL_Expiration(t *testing.T) {
-	cache, err := cache.NewMemcachedCache("localhost:11211")
+	c, err := cache.NewMemcachedCache("localhost:11211")
 	if err != nil {
 		t.Fatalf("Failed to create Memcached cache: %v", err)
 	}
 
-	err = cache.Set("key1", "value1", 1*time.Second)
+	err = c.Set("key1", "value1", 1*time.Second)
 	if err != nil {
 		t.Fatalf("Failed to set value with TTL: %v", err)
 	}
 
-	value, err := cache.Get("key1")
+	value, err := c.Get("key1")
 	if err != nil || value != "value1" {
 		t.Fatalf("Expected value1, got %v, error: %v", value, err)
 	}
 
 	time.Sleep(2 * time.Second)
 
-	value, err = cache.Get("key1")
+	value, err = c.Get("key1")
 
-	if err != nil && err.Error() != "memcache: cache miss" {
+	if !errors.Is(err, cache.ErrNotFound) {
 		t.Fatalf("Expected cache miss error, got: %v", err)
 	}
 
@@ -74,3 +84,234 @@ func TestMemcachedCache_TTL_Expiration(t *testing.T) {
 		t.Fatalf("Expected empty value, got: %v", value)
 	}
 }
+
+func TestMemcachedCache_KeyIndex(t *testing.T) {
+	c, err := cache.NewMemcachedCache("localhost:11211", cache.WithKeyIndex())
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cache: %v", err)
+	}
+
+	c.Set("user:1", "a", time.Minute)
+	c.Set("user:2", "b", time.Minute)
+	c.Set("order:1", "c", time.Minute)
+
+	all, err := c.GetAll()
+	if err != nil || len(all) != 3 || all["user:2"] != "b" {
+		t.Fatalf("Expected 3 indexed entries, got %v %v", all, err)
+	}
+
+	keys, _ := c.Keys("user:")
+	if len(keys) != 2 || keys[0] != "user:1" {
+		t.Fatalf("Expected the two user keys, got %v", keys)
+	}
+
+	deleted, err := c.DeletePrefix("user:")
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 deleted keys, got %d %v", deleted, err)
+	}
+	if _, err := c.Get("user:1"); !errors.Is(err, cache.ErrNotFound) {
+		t.Fatalf("Expected user:1 to be deleted, got %v", err)
+	}
+}
+
+func TestMemcachedCache_ParseServer(t *testing.T) {
+	server, err := cache.ParseMemcachedServer("10.0.0.1:11211 3")
+	if err != nil || server.Addr != "10.0.0.1:11211" || server.Weight != 3 {
+		t.Fatalf("Expected 10.0.0.1:11211 with weight 3, got %+v %v", server, err)
+	}
+	server, err = cache.ParseMemcachedServer("10.0.0.1:11211")
+	if err != nil || server.Weight != 1 {
+		t.Fatalf("Expected a default weight of 1, got %+v %v", server, err)
+	}
+	if _, err := cache.ParseMemcachedServer("10.0.0.1:11211 heavy"); err == nil {
+		t.Fatal("Expected an error for a non-numeric weight")
+	}
+}
+
+func TestMemcachedCache_ClusterSkipsDeadServers(t *testing.T) {
+	c, err := cache.NewMemcachedCluster([]cache.MemcachedServer{
+		{Addr: "localhost:11211", Weight: 1},
+		{Addr: "localhost:1", Weight: 1},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cluster: %v", err)
+	}
+	if live := c.LiveServers(); len(live) != 1 {
+		t.Fatalf("Expected one live server, got %v", live)
+	}
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("cluster:%d", i)
+		if err := c.Set(key, "value", time.Minute); err != nil {
+			t.Fatalf("Expected every key to rehash onto the live server, got %v", err)
+		}
+	}
+}
+
+// fakeMemcached answers version and set commands, recording the keys it
+// stores. Once hang is set it keeps reading commands without replying.
+type fakeMemcached struct {
+	listener net.Listener
+	hang     atomic.Bool
+
+	mutex sync.Mutex
+	keys  map[string]bool
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeMemcached{listener: listener, keys: make(map[string]bool)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || f.hang.Load() {
+			continue
+		}
+		switch fields[0] {
+		case "version":
+			fmt.Fprint(conn, "VERSION 1.6.0\r\n")
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+				return
+			}
+			f.mutex.Lock()
+			f.keys[fields[1]] = true
+			f.mutex.Unlock()
+			fmt.Fprint(conn, "STORED\r\n")
+		}
+	}
+}
+
+func (f *fakeMemcached) anyKey() (string, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for key := range f.keys {
+		return key, true
+	}
+	return "", false
+}
+
+func TestMemcachedCache_ConcurrentFailuresMarkOneServer(t *testing.T) {
+	servers := []*fakeMemcached{newFakeMemcached(t), newFakeMemcached(t), newFakeMemcached(t)}
+	var cluster []cache.MemcachedServer
+	for _, server := range servers {
+		cluster = append(cluster, cache.MemcachedServer{Addr: server.listener.Addr().String(), Weight: 1})
+	}
+	c, err := cache.NewMemcachedCluster(cluster)
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cluster: %v", err)
+	}
+	c.SetTimeout(500 * time.Millisecond)
+
+	for i := 0; i < 30; i++ {
+		if err := c.Set(fmt.Sprintf("key%d", i), "value", time.Minute); err != nil {
+			t.Fatalf("Failed to set value: %v", err)
+		}
+	}
+	key, found := servers[0].anyKey()
+	if !found {
+		t.Fatal("Expected some keys on the first server")
+	}
+	servers[0].hang.Store(true)
+
+	// Both writes go to the hung server, and the second fails after the
+	// first has already taken it out of the ring
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Set(key, "value", time.Minute); !errors.Is(err, cache.ErrBackendUnavailable) {
+				t.Errorf("Expected the hung server to be unavailable, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if live := c.LiveServers(); len(live) != 2 {
+		t.Fatalf("Expected only the hung server to be marked dead, got %v alive", live)
+	}
+}
+
+func TestMemcachedCache_CompressedCodec(t *testing.T) {
+	c, err := cache.NewMemcachedCache("localhost:11211", cache.WithCodec(cache.Compress(cache.GobCodec{}, 1024)))
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cache: %v", err)
+	}
+
+	// Compressed below memcached's item size limit
+	large := strings.Repeat("x", 2<<20)
+	if err := c.Set("compressed", large, time.Minute); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if value, err := c.Get("compressed"); err != nil || value != large {
+		t.Fatalf("Expected the large value back, got %v", err)
+	}
+}
+
+func TestMemcachedCache_SetIfMatch(t *testing.T) {
+	c, err := cache.NewMemcachedCache("localhost:11211")
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cache: %v", err)
+	}
+
+	ctx := context.Background()
+	c.Set("etag:1", "value1", time.Minute)
+	etag, _ := cache.ETag("value1")
+	if err := c.SetIfMatch(ctx, "etag:1", "value2", time.Minute, etag); err != nil {
+		t.Fatalf("Expected the write to succeed, got %v", err)
+	}
+	if err := c.SetIfMatch(ctx, "etag:1", "value3", time.Minute, etag); !errors.Is(err, cache.ErrVersionMismatch) {
+		t.Fatalf("Expected ErrVersionMismatch for a stale ETag, got %v", err)
+	}
+}
+
+func TestMemcachedCache_AtomicWrites(t *testing.T) {
+	c, err := cache.NewMemcachedCache("localhost:11211")
+	if err != nil {
+		t.Fatalf("Failed to create Memcached cache: %v", err)
+	}
+
+	ctx := context.Background()
+	c.Delete("atomic:1")
+	if stored, err := c.SetNX(ctx, "atomic:1", "a", time.Minute); err != nil || !stored {
+		t.Fatalf("Expected SetNX to store a missing key, got %v %v", stored, err)
+	}
+	if stored, err := c.SetNX(ctx, "atomic:1", "b", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetNX to skip an existing key, got %v %v", stored, err)
+	}
+	if swapped, err := c.CompareAndSwap(ctx, "atomic:1", "b", "c", time.Minute); err != nil || swapped {
+		t.Fatalf("Expected a swap with a stale old value to fail, got %v %v", swapped, err)
+	}
+	if swapped, err := c.CompareAndSwap(ctx, "atomic:1", "a", "c", time.Minute); err != nil || !swapped {
+		t.Fatalf("Expected the swap to succeed, got %v %v", swapped, err)
+	}
+	if stored, err := c.SetXX(ctx, "atomic:missing", "d", time.Minute); err != nil || stored {
+		t.Fatalf("Expected SetXX to skip a missing key, got %v %v", stored, err)
+	}
+}